@@ -0,0 +1,182 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mzky/utils/common"
+)
+
+// Format 压缩包格式
+type Format int
+
+const (
+	Unknown Format = iota
+	Zip
+	Tar
+	TarGz
+	TarZst
+)
+
+// String 格式名称
+func (f Format) String() string {
+	switch f {
+	case Zip:
+		return "zip"
+	case Tar:
+		return "tar"
+	case TarGz:
+		return "tar.gz"
+	case TarZst:
+		return "tar.zst"
+	default:
+		return "unknown"
+	}
+}
+
+// Ext 格式对应的文件后缀
+func (f Format) Ext() string {
+	if f == Unknown {
+		return ""
+	}
+	return "." + f.String()
+}
+
+var ErrUnknownFormat = errors.New("无法识别的压缩包格式")
+
+// Archiver 统一的打包、解包接口，均以流的方式读写，可直接写入http响应
+type Archiver interface {
+	// Archive 将files打包写入w，包内路径为相对root的路径，root为空时以各文件所在目录为基准
+	// 目录会递归加入
+	Archive(w io.Writer, root string, files ...string) error
+	// Extract 从r读取压缩包并解压到dest目录，条目路径和符号链接不允许逃逸出dest，不经过已存在的符号链接写入
+	Extract(r io.Reader, dest string) error
+}
+
+// New 根据格式创建Archiver，zip格式可使用NewZip设置密码，需要解压限制时直接构造ZipArchiver或TarArchiver
+func New(f Format) (Archiver, error) {
+	switch f {
+	case Zip:
+		return NewZip(""), nil
+	case Tar, TarGz, TarZst:
+		return &TarArchiver{Format: f}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// FormatByName 根据文件后缀判断格式
+func FormatByName(name string) Format {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return Zip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return TarGz
+	case strings.HasSuffix(name, ".tar.zst"), strings.HasSuffix(name, ".tzst"):
+		return TarZst
+	case strings.HasSuffix(name, ".tar"):
+		return Tar
+	default:
+		return Unknown
+	}
+}
+
+// 识别格式需要读取的字节数，tar的ustar标识位于257偏移处
+const magicLen = 262
+
+// Detect 根据魔数识别格式，返回的io.Reader包含已读取的头部数据，可继续用于解压
+func Detect(r io.Reader) (Format, io.Reader, error) {
+	br := bufio.NewReaderSize(r, magicLen)
+	head, err := br.Peek(magicLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Unknown, br, err
+	}
+	return detect(head), br, nil
+}
+
+// DetectFile 根据魔数识别文件格式
+func DetectFile(fp string) (Format, error) {
+	f, err := os.Open(fp)
+	if err != nil {
+		return Unknown, err
+	}
+	defer f.Close()
+
+	format, _, err := Detect(f)
+	return format, err
+}
+
+func detect(head []byte) Format {
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return Zip
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return TarGz
+	case bytes.HasPrefix(head, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return TarZst
+	case len(head) >= magicLen && bytes.HasPrefix(head[257:], []byte("ustar")):
+		return Tar
+	default:
+		return Unknown
+	}
+}
+
+// Create 创建压缩包，格式由archivePath的后缀决定
+func Create(archivePath, root string, files ...string) error {
+	a, err := New(FormatByName(archivePath))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	if err = a.Archive(f, root, files...); err != nil {
+		_ = f.Close()
+		_ = os.Remove(archivePath)
+		return err
+	}
+	return f.Close()
+}
+
+// Extract 解压压缩包到dest目录，格式根据魔数识别，不限制解压大小，来源不可信时使用ExtractWithOptions
+func Extract(archivePath, dest string) error {
+	return ExtractWithOptions(archivePath, dest, common.UnZipOptions{})
+}
+
+// ExtractWithOptions 按选项解压，opts中的限制对zip和tar格式均生效，超出限制时返回common.ErrZipPolicy
+func ExtractWithOptions(archivePath, dest string, opts common.UnZipOptions) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	format, r, err := Detect(f)
+	if err != nil {
+		return err
+	}
+	var a Archiver
+	switch format {
+	case Zip:
+		a = &ZipArchiver{Limits: opts}
+	case Tar, TarGz, TarZst:
+		a = &TarArchiver{Format: format, Limits: opts}
+	default:
+		return ErrUnknownFormat
+	}
+	if format == Zip {
+		// zip需要随机读取，直接使用文件避免落盘临时文件
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r = f
+	}
+	return a.Extract(r, dest)
+}
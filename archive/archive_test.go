@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mzky/utils/common"
+)
+
+// tarEntry 测试用的tar条目，link不为空时为符号链接
+type tarEntry struct {
+	name, link, body string
+}
+
+func makeTar(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		if e.link != "" {
+			hdr = &tar.Header{Name: e.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: e.link}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []Format{Zip, Tar, TarGz, TarZst} {
+		name := filepath.Join(dir, "out"+f.Ext())
+		if err := Create(name, "", src); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		if got, err := DetectFile(name); err != nil || got != f {
+			t.Fatalf("DetectFile = %s, %v, want %s", got, err, f)
+		}
+		dest := filepath.Join(dir, f.String())
+		if err := Extract(name, dest); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		if b, err := os.ReadFile(filepath.Join(dest, "src", "sub", "a.txt")); err != nil || string(b) != "hello" {
+			t.Fatalf("%s: content = %q, %v", f, b, err)
+		}
+	}
+}
+
+func TestTarEscape(t *testing.T) {
+	cases := map[string][]tarEntry{
+		"dotdot":   {{name: "../evil.txt", body: "x"}},
+		"absolute": {{name: "/evil.txt", body: "x"}},
+		"link abs": {{name: "l1", link: "/"}, {name: "l1/evil.txt", body: "x"}},
+		"link up":  {{name: "l1", link: ".."}, {name: "l1/evil.txt", body: "x"}},
+		// 每个链接单独按字符串检查都在dest内，组合后l2指向dest的上级目录
+		"chain": {{name: "l1", link: "."}, {name: "l1/l2", link: ".."}, {name: "l2/evil.txt", body: "x"}},
+		// 目标经过已解压的链接再返回上级
+		"via link": {{name: "l1", link: "."}, {name: "l2", link: "l1/.."}, {name: "l2/evil.txt", body: "x"}},
+	}
+	for name, entries := range cases {
+		t.Run(name, func(t *testing.T) {
+			parent := t.TempDir()
+			dest := filepath.Join(parent, "dest")
+			a, _ := New(Tar)
+			if err := a.Extract(makeTar(t, entries...), dest); err == nil {
+				t.Fatal("Extract应返回错误")
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil.txt")); err == nil {
+				t.Fatal("文件被写到解压目录之外")
+			}
+		})
+	}
+
+	// 解压目录中已存在指向外部的符号链接时不跟随写入
+	parent := t.TempDir()
+	outside := filepath.Join(parent, "outside.txt")
+	dest := filepath.Join(parent, "dest")
+	if err := os.WriteFile(outside, []byte("orig"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dest, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "l1")); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := New(Tar)
+	if err := a.Extract(makeTar(t, tarEntry{name: "l1", body: "x"}), dest); err == nil {
+		t.Fatal("Extract应返回错误")
+	}
+	if b, _ := os.ReadFile(outside); string(b) != "orig" {
+		t.Fatalf("外部文件被改写为 %q", b)
+	}
+
+	// 指向dest内的链接正常解压
+	dest = t.TempDir()
+	err := a.Extract(makeTar(t, tarEntry{name: "a/b.txt", body: "x"}, tarEntry{name: "c", link: "a/b.txt"}), dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dest, "c")); err != nil || string(b) != "x" {
+		t.Fatalf("content = %q, %v", b, err)
+	}
+}
+
+func TestTarLimits(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(src, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for name, size := range map[string]int{"a.txt": 10, "b.txt": 10, "zeros": 1 << 20} {
+		if err := os.WriteFile(filepath.Join(src, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, f := range []Format{Tar, TarGz, TarZst} {
+		name := filepath.Join(dir, "bomb"+f.Ext())
+		if err := Create(name, src, src); err != nil {
+			t.Fatal(err)
+		}
+		cases := map[string]common.UnZipOptions{
+			"MaxEntries":   {MaxEntries: 2},
+			"MaxTotalSize": {MaxTotalSize: 1 << 19},
+		}
+		if f != Tar {
+			cases["MaxRatio"] = common.UnZipOptions{MaxRatio: 100}
+		}
+		for limit, opts := range cases {
+			dest := t.TempDir()
+			err := ExtractWithOptions(name, dest, opts)
+			if !errors.Is(err, common.ErrZipPolicy) {
+				t.Fatalf("%s %s: err = %v, want ErrZipPolicy", f, limit, err)
+			}
+			if _, err := os.Stat(filepath.Join(dest, "zeros")); err == nil {
+				t.Fatalf("%s %s: 超出限制的文件未删除", f, limit)
+			}
+		}
+		opts := common.UnZipOptions{MaxEntries: 3, MaxTotalSize: 1<<20 + 20, MaxRatio: 1 << 20}
+		if err := ExtractWithOptions(name, t.TempDir(), opts); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+
+	a := &TarArchiver{Format: TarGz}
+	if err := a.Extract(bytes.NewReader([]byte{0x1f, 0x8b, 0, 0}), t.TempDir()); !errors.Is(err, common.ErrZipCorrupt) {
+		t.Fatalf("err = %v, want ErrZipCorrupt", err)
+	}
+	data := makeTar(t, tarEntry{name: "a.txt", body: "hello"}).Bytes()
+	a = &TarArchiver{Format: Tar}
+	if err := a.Extract(bytes.NewReader(data[:514]), t.TempDir()); !errors.Is(err, common.ErrZipCorrupt) {
+		t.Fatalf("err = %v, want ErrZipCorrupt", err)
+	}
+}
@@ -0,0 +1,188 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mzky/utils/common"
+	"github.com/mzky/utils/internal/fsutil"
+)
+
+// TarArchiver tar、tar.gz、tar.zst格式
+type TarArchiver struct {
+	Format Format
+	// Limits 解压限制，与zip相同，MaxRatio按整个压缩流计算
+	// Password和Symlinks不生效，符号链接只允许指向解压目录内
+	Limits common.UnZipOptions
+}
+
+// compress 按格式包装压缩层
+func (t *TarArchiver) compress(w io.Writer) (io.WriteCloser, error) {
+	switch t.Format {
+	case TarGz:
+		return gzip.NewWriter(w), nil
+	case TarZst:
+		return zstd.NewWriter(w)
+	default:
+		return fsutil.NopWriteCloser{Writer: w}, nil
+	}
+}
+
+// decompress 按格式包装解压层
+func (t *TarArchiver) decompress(r io.Reader) (io.ReadCloser, error) {
+	switch t.Format {
+	case TarGz:
+		return gzip.NewReader(r)
+	case TarZst:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
+
+func (t *TarArchiver) Archive(w io.Writer, root string, files ...string) error {
+	cw, err := t.compress(w)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+
+	err = fsutil.Walk(root, files, func(fp, name string, fi fs.FileInfo) error {
+		var link string
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(fp); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = name
+		if fi.IsDir() {
+			hdr.Name += "/"
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(tw, fp)
+	})
+	if err != nil {
+		_ = tw.Close()
+		_ = cw.Close()
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		_ = cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// Extract 超出Limits、路径逃逸时返回common.ErrZipPolicy，数据损坏时返回common.ErrZipCorrupt
+func (t *TarArchiver) Extract(r io.Reader, dest string) error {
+	in := &countingReader{r: r}
+	dr, err := t.decompress(in)
+	if err != nil {
+		return &common.ZipEntryError{Kind: common.ErrZipCorrupt, Err: err}
+	}
+	defer dr.Close()
+
+	var total int64
+	tr := tar.NewReader(dr)
+	for n := 1; ; n++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return &common.ZipEntryError{Kind: common.ErrZipCorrupt, Err: err}
+		}
+		if t.Limits.MaxEntries > 0 && n > t.Limits.MaxEntries {
+			return &common.ZipEntryError{Kind: common.ErrZipPolicy, Err: fmt.Errorf("条目数超过上限 %d", t.Limits.MaxEntries)}
+		}
+
+		fp, err := fsutil.Join(dest, hdr.Name)
+		if err != nil {
+			return &common.ZipEntryError{Name: hdr.Name, Kind: common.ErrZipPolicy, Err: err}
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(fp, os.ModePerm)
+		case tar.TypeReg:
+			er := &entryReader{r: tr, name: hdr.Name, in: in, total: &total, limits: t.Limits}
+			if _, err = fsutil.WriteFile(fp, er, hdr.FileInfo().Mode()); err != nil {
+				_ = os.Remove(fp)
+			}
+		case tar.TypeSymlink:
+			err = fsutil.Symlink(dest, fp, hdr.Linkname)
+		default:
+			// 设备文件、硬链接等不做处理
+		}
+		if errors.Is(err, fsutil.ErrUnsafePath) {
+			return &common.ZipEntryError{Name: hdr.Name, Kind: common.ErrZipPolicy, Err: err}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// countingReader 统计读取的压缩数据字节数，用于计算压缩比
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// entryReader 读取条目内容，累计解压后的字节数并检查限制，读取出错时视为压缩包损坏
+type entryReader struct {
+	r      io.Reader
+	name   string
+	in     *countingReader
+	total  *int64
+	limits common.UnZipOptions
+}
+
+func (e *entryReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	*e.total += int64(n)
+	switch {
+	case e.limits.MaxTotalSize > 0 && *e.total > e.limits.MaxTotalSize:
+		return n, &common.ZipEntryError{Name: e.name, Kind: common.ErrZipPolicy, Err: errors.New("解压后大小超过限制")}
+	case e.limits.MaxRatio > 0 && *e.total > e.in.n*e.limits.MaxRatio:
+		return n, &common.ZipEntryError{Name: e.name, Kind: common.ErrZipPolicy, Err: errors.New("压缩比超过限制")}
+	case err != nil && !errors.Is(err, io.EOF):
+		return n, &common.ZipEntryError{Name: e.name, Kind: common.ErrZipCorrupt, Err: err}
+	}
+	return n, err
+}
+
+func copyFile(w io.Writer, fp string) error {
+	f, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
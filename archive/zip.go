@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"io"
+	"os"
+
+	"github.com/mzky/utils/common"
+)
+
+// ZipArchiver zip格式，打包和解压使用common.ZipTo、common.UnZipReader
+type ZipArchiver struct {
+	// Password 为空时不加密
+	Password string
+	// Encryption 加密方式，默认AES-256
+	Encryption common.ZipEncryption
+	// Limits 解压限制和符号链接处理方式，其中Password为空时使用ZipArchiver.Password
+	Limits common.UnZipOptions
+}
+
+// NewZip 创建zip格式的Archiver，password值可以为空""
+func NewZip(password string) *ZipArchiver {
+	return &ZipArchiver{Password: password}
+}
+
+func (z *ZipArchiver) Archive(w io.Writer, root string, files ...string) error {
+	return common.ZipTo(w, files, common.ZipOptions{
+		Password:   z.Password,
+		Encryption: z.Encryption,
+		BaseDir:    root,
+	})
+}
+
+// Extract zip需要随机读取，r不支持io.ReaderAt时先写入临时文件
+func (z *ZipArchiver) Extract(r io.Reader, dest string) error {
+	ra, size, cleanup, err := readerAt(r)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	opts := z.Limits
+	if opts.Password == "" {
+		opts.Password = z.Password
+	}
+	return common.UnZipReader(ra, size, dest, opts)
+}
+
+// readerAt 获取可随机读取的数据源及其长度
+func readerAt(r io.Reader) (io.ReaderAt, int64, func(), error) {
+	if f, ok := r.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+			return f, fi.Size(), func() {}, nil
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "archive-*.zip")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return tmp, size, cleanup, nil
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"errors"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -52,26 +53,14 @@ func ContainsDuplicate(str []string) bool {
 	return false
 }
 
-// RemoveRepeatedElement 删除重复元素
+// RemoveRepeatedElement 删除重复元素，返回排序后的新数组，不修改arr，保留原顺序请使用Unique
 func RemoveRepeatedElement(arr []string) (newArr []string) {
-	newArr = make([]string, 0)
-	sort.Strings(arr)
-	for i := 0; i < len(arr); i++ {
-		repeat := false
-		for j := i + 1; j < len(arr); j++ {
-			if arr[i] == arr[j] {
-				repeat = true
-				break
-			}
-		}
-		if !repeat {
-			newArr = append(newArr, arr[i])
-		}
-	}
-	return
+	newArr = append(make([]string, 0, len(arr)), arr...)
+	sort.Strings(newArr)
+	return slices.Compact(newArr)
 }
 
-// ArrayContains array中是否存在指定数据
+// ArrayContains array中是否存在指定数据，比较时忽略首尾空白，精确匹配请使用Contains
 func ArrayContains(array []string, val string) bool {
 	for _, v := range array {
 		if strings.TrimSpace(v) == strings.TrimSpace(val) {
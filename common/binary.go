@@ -0,0 +1,373 @@
+package common
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrShortBuffer = errors.New("数据长度不足")
+	ErrTooLong     = errors.New("数据长度超出长度字段范围")
+)
+
+// UintFormat 长度、标签等无符号整数字段的编码方式
+type UintFormat int
+
+const (
+	UvarintFormat UintFormat = 0
+	Uint8Format   UintFormat = 1
+	Uint16Format  UintFormat = 2
+	Uint32Format  UintFormat = 4
+)
+
+// BinaryWriter 构造二进制数据，出错后后续写入均被忽略，最后通过Err检查
+type BinaryWriter struct {
+	buf   []byte
+	order binary.AppendByteOrder
+	err   error
+}
+
+// NewBinaryWriter order为binary.BigEndian、binary.LittleEndian或binary.NativeEndian
+func NewBinaryWriter(order binary.AppendByteOrder) *BinaryWriter {
+	return &BinaryWriter{order: order}
+}
+
+// Bytes 已写入的数据
+func (w *BinaryWriter) Bytes() []byte { return w.buf }
+
+// Len 已写入的字节数
+func (w *BinaryWriter) Len() int { return len(w.buf) }
+
+// Err 第一个写入错误
+func (w *BinaryWriter) Err() error { return w.err }
+
+func (w *BinaryWriter) Uint8(v uint8) *BinaryWriter {
+	if w.err == nil {
+		w.buf = append(w.buf, v)
+	}
+	return w
+}
+
+func (w *BinaryWriter) Uint16(v uint16) *BinaryWriter {
+	if w.err == nil {
+		w.buf = w.order.AppendUint16(w.buf, v)
+	}
+	return w
+}
+
+func (w *BinaryWriter) Uint32(v uint32) *BinaryWriter {
+	if w.err == nil {
+		w.buf = w.order.AppendUint32(w.buf, v)
+	}
+	return w
+}
+
+func (w *BinaryWriter) Uint64(v uint64) *BinaryWriter {
+	if w.err == nil {
+		w.buf = w.order.AppendUint64(w.buf, v)
+	}
+	return w
+}
+
+func (w *BinaryWriter) Int8(v int8) *BinaryWriter   { return w.Uint8(uint8(v)) }
+func (w *BinaryWriter) Int16(v int16) *BinaryWriter { return w.Uint16(uint16(v)) }
+func (w *BinaryWriter) Int32(v int32) *BinaryWriter { return w.Uint32(uint32(v)) }
+func (w *BinaryWriter) Int64(v int64) *BinaryWriter { return w.Uint64(uint64(v)) }
+
+func (w *BinaryWriter) Float32(v float32) *BinaryWriter { return w.Uint32(math.Float32bits(v)) }
+func (w *BinaryWriter) Float64(v float64) *BinaryWriter { return w.Uint64(math.Float64bits(v)) }
+
+// UintN 写入n(1-8)字节的无符号整数，如3字节长度字段
+func (w *BinaryWriter) UintN(v uint64, n int) *BinaryWriter {
+	if w.err != nil {
+		return w
+	}
+	if n < 1 || n > 8 || n < 8 && v>>(8*n) != 0 {
+		w.err = fmt.Errorf("%w: %d 无法用%d字节表示", ErrTooLong, v, n)
+		return w
+	}
+	b := make([]byte, 8)
+	// 按行为判断字节序，binary.NativeEndian与LittleEndian不是同一个值
+	if w.order.AppendUint16(nil, 1)[0] == 1 {
+		binary.LittleEndian.PutUint64(b, v)
+		w.buf = append(w.buf, b[:n]...)
+	} else {
+		binary.BigEndian.PutUint64(b, v)
+		w.buf = append(w.buf, b[8-n:]...)
+	}
+	return w
+}
+
+// Uvarint 写入无符号变长整数
+func (w *BinaryWriter) Uvarint(v uint64) *BinaryWriter {
+	if w.err == nil {
+		w.buf = binary.AppendUvarint(w.buf, v)
+	}
+	return w
+}
+
+// Varint 写入有符号变长整数(zigzag编码)
+func (w *BinaryWriter) Varint(v int64) *BinaryWriter {
+	if w.err == nil {
+		w.buf = binary.AppendVarint(w.buf, v)
+	}
+	return w
+}
+
+// Raw 写入原始数据
+func (w *BinaryWriter) Raw(b []byte) *BinaryWriter {
+	if w.err == nil {
+		w.buf = append(w.buf, b...)
+	}
+	return w
+}
+
+// Uint 按format写入无符号整数
+func (w *BinaryWriter) Uint(v uint64, format UintFormat) *BinaryWriter {
+	if format == UvarintFormat {
+		return w.Uvarint(v)
+	}
+	return w.UintN(v, int(format))
+}
+
+// LenBytes 写入按format编码的长度前缀及数据
+func (w *BinaryWriter) LenBytes(b []byte, format UintFormat) *BinaryWriter {
+	return w.Uint(uint64(len(b)), format).Raw(b)
+}
+
+// LenString 写入按format编码的长度前缀及字符串
+func (w *BinaryWriter) LenString(s string, format UintFormat) *BinaryWriter {
+	return w.LenBytes([]byte(s), format)
+}
+
+// BinaryReader 按顺序读取二进制数据并检查边界，出错后后续读取均返回零值，最后通过Err检查
+type BinaryReader struct {
+	data  []byte
+	off   int
+	order binary.ByteOrder
+	err   error
+}
+
+// NewBinaryReader order为binary.BigEndian、binary.LittleEndian或binary.NativeEndian
+func NewBinaryReader(data []byte, order binary.ByteOrder) *BinaryReader {
+	return &BinaryReader{data: data, order: order}
+}
+
+// Err 第一个读取错误
+func (r *BinaryReader) Err() error { return r.err }
+
+// Offset 当前读取位置
+func (r *BinaryReader) Offset() int { return r.off }
+
+// Remaining 剩余未读取的字节数
+func (r *BinaryReader) Remaining() int { return len(r.data) - r.off }
+
+// next 读取n字节，不足时记录错误
+func (r *BinaryReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > r.Remaining() {
+		r.err = fmt.Errorf("%w: 偏移%d处需要%d字节，剩余%d字节", ErrShortBuffer, r.off, n, r.Remaining())
+		return nil
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *BinaryReader) Uint8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *BinaryReader) Uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return r.order.Uint16(b)
+	}
+	return 0
+}
+
+func (r *BinaryReader) Uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return r.order.Uint32(b)
+	}
+	return 0
+}
+
+func (r *BinaryReader) Uint64() uint64 {
+	if b := r.next(8); b != nil {
+		return r.order.Uint64(b)
+	}
+	return 0
+}
+
+func (r *BinaryReader) Int8() int8   { return int8(r.Uint8()) }
+func (r *BinaryReader) Int16() int16 { return int16(r.Uint16()) }
+func (r *BinaryReader) Int32() int32 { return int32(r.Uint32()) }
+func (r *BinaryReader) Int64() int64 { return int64(r.Uint64()) }
+
+func (r *BinaryReader) Float32() float32 { return math.Float32frombits(r.Uint32()) }
+func (r *BinaryReader) Float64() float64 { return math.Float64frombits(r.Uint64()) }
+
+// UintN 读取n(1-8)字节的无符号整数
+func (r *BinaryReader) UintN(n int) uint64 {
+	if r.err == nil && (n < 1 || n > 8) {
+		r.err = fmt.Errorf("整数宽度 %d 不在1-8之间", n)
+	}
+	b := r.next(n)
+	if b == nil {
+		return 0
+	}
+	var v uint64
+	little := r.order.Uint16([]byte{1, 0}) == 1
+	for i := range b {
+		if little {
+			v |= uint64(b[i]) << (8 * i)
+		} else {
+			v = v<<8 | uint64(b[i])
+		}
+	}
+	return v
+}
+
+// Uvarint 读取无符号变长整数
+func (r *BinaryReader) Uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.off:])
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: 偏移%d处的变长整数不完整或溢出", ErrShortBuffer, r.off)
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+// Varint 读取有符号变长整数
+func (r *BinaryReader) Varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data[r.off:])
+	if n <= 0 {
+		r.err = fmt.Errorf("%w: 偏移%d处的变长整数不完整或溢出", ErrShortBuffer, r.off)
+		return 0
+	}
+	r.off += n
+	return v
+}
+
+// Raw 读取n字节，返回的切片与原数据共享内存
+func (r *BinaryReader) Raw(n int) []byte {
+	return r.next(n)
+}
+
+// Uint 按format读取无符号整数
+func (r *BinaryReader) Uint(format UintFormat) uint64 {
+	if format == UvarintFormat {
+		return r.Uvarint()
+	}
+	return r.UintN(int(format))
+}
+
+// LenBytes 读取按format编码的长度前缀及数据
+func (r *BinaryReader) LenBytes(format UintFormat) []byte {
+	n := r.Uint(format)
+	if r.err == nil && n > uint64(r.Remaining()) {
+		r.err = fmt.Errorf("%w: 偏移%d处长度字段为%d，剩余%d字节", ErrShortBuffer, r.off, n, r.Remaining())
+		return nil
+	}
+	return r.next(int(n))
+}
+
+// LenString 读取按format编码的长度前缀及字符串
+func (r *BinaryReader) LenString(format UintFormat) string {
+	return string(r.LenBytes(format))
+}
+
+// TLV 标签-长度-值结构，Children不为空时Value由子项编码而成
+type TLV struct {
+	Tag      uint64
+	Value    []byte
+	Children []TLV
+}
+
+// TLVCodec TLV编解码，标签和长度字段的编码方式可分别指定
+type TLVCodec struct {
+	Order interface {
+		binary.ByteOrder
+		binary.AppendByteOrder
+	}
+	TagFormat UintFormat
+	LenFormat UintFormat
+	// Constructed 返回true的标签在解码时将Value递归解析为Children
+	Constructed func(tag uint64) bool
+	// MaxDepth 嵌套层数上限，默认16
+	MaxDepth int
+}
+
+// Encode 编码TLV列表
+func (c TLVCodec) Encode(items []TLV) ([]byte, error) {
+	w := NewBinaryWriter(c.Order)
+	if err := c.encode(w, items); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), w.Err()
+}
+
+func (c TLVCodec) encode(w *BinaryWriter, items []TLV) error {
+	for _, item := range items {
+		value := item.Value
+		if len(item.Children) > 0 {
+			var err error
+			if value, err = c.Encode(item.Children); err != nil {
+				return err
+			}
+		}
+		w.Uint(item.Tag, c.TagFormat).LenBytes(value, c.LenFormat)
+		if w.Err() != nil {
+			return fmt.Errorf("标签 %d: %w", item.Tag, w.Err())
+		}
+	}
+	return nil
+}
+
+// Decode 解码TLV列表，数据不完整或长度越界时返回ErrShortBuffer
+func (c TLVCodec) Decode(data []byte) ([]TLV, error) {
+	return c.decode(data, 0)
+}
+
+func (c TLVCodec) decode(data []byte, depth int) ([]TLV, error) {
+	maxDepth := c.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 16
+	}
+	if depth > maxDepth {
+		return nil, fmt.Errorf("TLV嵌套超过%d层", maxDepth)
+	}
+
+	r := NewBinaryReader(data, c.Order)
+	items := make([]TLV, 0)
+	for r.Remaining() > 0 {
+		item := TLV{Tag: r.Uint(c.TagFormat)}
+		item.Value = r.LenBytes(c.LenFormat)
+		if r.Err() != nil {
+			return nil, r.Err()
+		}
+		if c.Constructed != nil && c.Constructed(item.Tag) {
+			children, err := c.decode(item.Value, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("标签 %d: %w", item.Tag, err)
+			}
+			item.Children = children
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,29 @@
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestBinaryUintNNativeEndian(t *testing.T) {
+	for _, order := range []interface {
+		binary.ByteOrder
+		binary.AppendByteOrder
+	}{binary.BigEndian, binary.LittleEndian, binary.NativeEndian} {
+		w := NewBinaryWriter(order).UintN(0x010203, 3).Uint32(0x04050607)
+		want := order.AppendUint32(nil, 0x04050607)
+		if order.Uint16([]byte{1, 0}) == 1 {
+			want = append([]byte{3, 2, 1}, want...)
+		} else {
+			want = append([]byte{1, 2, 3}, want...)
+		}
+		if !bytes.Equal(w.Bytes(), want) {
+			t.Fatalf("%v: % x, want % x", order, w.Bytes(), want)
+		}
+		r := NewBinaryReader(w.Bytes(), order)
+		if v := r.UintN(3); v != 0x010203 {
+			t.Fatalf("%v: UintN = %#x", order, v)
+		}
+	}
+}
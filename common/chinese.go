@@ -0,0 +1,223 @@
+package common
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/width"
+)
+
+// Charset 字符编码
+type Charset int
+
+const (
+	UnknownCharset Charset = iota
+	UTF8
+	GBK
+	GB18030
+)
+
+// String 编码名称
+func (c Charset) String() string {
+	switch c {
+	case UTF8:
+		return "UTF-8"
+	case GBK:
+		return "GBK"
+	case GB18030:
+		return "GB18030"
+	default:
+		return "unknown"
+	}
+}
+
+func (c Charset) encoding() encoding.Encoding {
+	switch c {
+	case GBK:
+		return simplifiedchinese.GBK
+	case GB18030:
+		return simplifiedchinese.GB18030
+	default:
+		return encoding.Nop
+	}
+}
+
+// DetectCharset 检测编码，纯ASCII视为UTF-8，包含四字节编码时为GB18030
+func DetectCharset(data []byte) Charset {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return UTF8
+	}
+
+	cs := GBK
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b < 0x80:
+			i++
+		case b == 0x80 || b == 0xff || i+1 >= len(data):
+			return UnknownCharset
+		case data[i+1] >= 0x30 && data[i+1] <= 0x39:
+			// GB18030四字节编码: [81-FE][30-39][81-FE][30-39]
+			if i+3 >= len(data) || data[i+2] < 0x81 || data[i+2] > 0xfe || data[i+3] < 0x30 || data[i+3] > 0x39 {
+				return UnknownCharset
+			}
+			cs = GB18030
+			i += 4
+		case data[i+1] >= 0x40 && data[i+1] <= 0xfe && data[i+1] != 0x7f:
+			i += 2
+		default:
+			return UnknownCharset
+		}
+	}
+	return cs
+}
+
+// ToUTF8 将from编码的数据转换为UTF-8
+func ToUTF8(data []byte, from Charset) ([]byte, error) {
+	if from == UTF8 {
+		return bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), nil
+	}
+	ret, _, err := transform.Bytes(from.encoding().NewDecoder(), data)
+	return ret, err
+}
+
+// FromUTF8 将UTF-8数据转换为to编码，GBK无法表示的字符会返回错误
+func FromUTF8(data []byte, to Charset) ([]byte, error) {
+	if to == UTF8 {
+		return data, nil
+	}
+	ret, _, err := transform.Bytes(to.encoding().NewEncoder(), data)
+	return ret, err
+}
+
+// AutoToUTF8 自动检测编码并转换为UTF-8，返回检测到的编码
+func AutoToUTF8(data []byte) ([]byte, Charset, error) {
+	cs := DetectCharset(data)
+	if cs == UnknownCharset {
+		// 无法识别时按GB18030解码，它是GBK的超集
+		cs = GB18030
+	}
+	ret, err := ToUTF8(data, cs)
+	return ret, cs, err
+}
+
+// NewUTF8Reader 将from编码的流转换为UTF-8
+func NewUTF8Reader(r io.Reader, from Charset) io.Reader {
+	return transform.NewReader(r, from.encoding().NewDecoder())
+}
+
+// NewCharsetWriter 将写入的UTF-8数据转换为to编码，必须调用Close写出剩余数据
+func NewCharsetWriter(w io.Writer, to Charset) io.WriteCloser {
+	return transform.NewWriter(w, to.encoding().NewEncoder())
+}
+
+// ReadFileUTF8 读取文件，自动检测编码并转换为UTF-8
+func ReadFileUTF8(filename string) (string, Charset, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", UnknownCharset, err
+	}
+	ret, cs, err := AutoToUTF8(data)
+	return string(ret), cs, err
+}
+
+// WriteFileCharset 将UTF-8内容按to编码写入文件
+func WriteFileCharset(filename, content string, to Charset) error {
+	data, err := FromUTF8([]byte(content), to)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// ToHalfWidth 全角ASCII字符(U+FF01-U+FF5E)和全角空格转换为半角，如"ＡＢＣ１２３，"转换为"ABC123,"
+// 中文标点(。「」)和片假名等不转换
+func ToHalfWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\u3000':
+			return ' '
+		case r >= '\uFF01' && r <= '\uFF5E':
+			return r - 0xFEE0
+		}
+		return r
+	}, s)
+}
+
+// ToFullWidth 可见ASCII字符和空格转换为全角，与ToHalfWidth互逆
+func ToFullWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '\u3000'
+		case r >= '!' && r <= '~':
+			return r + 0xFEE0
+		}
+		return r
+	}, s)
+}
+
+// RuneWidth 字符在终端中的显示宽度，中日韩文字及全角字符为2，组合字符为0
+func RuneWidth(r rune) int {
+	if r == 0 || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// DisplayWidth 字符串在终端中的显示宽度，用于表格对齐
+func DisplayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += RuneWidth(r)
+	}
+	return w
+}
+
+// PadRight 在右侧补空格至显示宽度w
+func PadRight(s string, w int) string {
+	if n := w - DisplayWidth(s); n > 0 {
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+// PadLeft 在左侧补空格至显示宽度w
+func PadLeft(s string, w int) string {
+	if n := w - DisplayWidth(s); n > 0 {
+		return strings.Repeat(" ", n) + s
+	}
+	return s
+}
+
+// TruncateWidth 截断至显示宽度w，截断时追加tail，tail的宽度计入w
+func TruncateWidth(s string, w int, tail string) string {
+	if DisplayWidth(s) <= w {
+		return s
+	}
+	w -= DisplayWidth(tail)
+	var b strings.Builder
+	cur := 0
+	for _, r := range s {
+		rw := RuneWidth(r)
+		if cur+rw > w {
+			break
+		}
+		cur += rw
+		b.WriteRune(r)
+	}
+	return b.String() + tail
+}
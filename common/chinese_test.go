@@ -0,0 +1,12 @@
+package common
+
+import "testing"
+
+func TestHalfFullWidth(t *testing.T) {
+	if got := ToHalfWidth("ＡＢＣ１２３，你好，世界。「引号」ア　～"); got != "ABC123,你好,世界。「引号」ア ~" {
+		t.Errorf("ToHalfWidth = %q", got)
+	}
+	if got := ToFullWidth("ABC 123, 你好。｡ｱ"); got != "ＡＢＣ　１２３，　你好。｡ｱ" {
+		t.Errorf("ToFullWidth = %q", got)
+	}
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时钟，Now为墙上时间，修改系统时间后会跳变；Monotonic为单调时间，不受修改系统时间影响
+type Clock interface {
+	// Now 当前墙上时间
+	Now() time.Time
+	// Monotonic 单调递增的时间，只用于计算间隔
+	Monotonic() time.Duration
+	// After 按单调时间等待d后返回当时的墙上时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock 使用系统时间的时钟
+var SystemClock Clock = systemClock{}
+
+var processStart = time.Now()
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	// 去掉单调时钟读数，保证比较、相减时使用墙上时间
+	return time.Now().Round(0)
+}
+
+func (systemClock) Monotonic() time.Duration {
+	return time.Since(processStart)
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// ClockJump 根据两次采样计算系统时间被调整的量，正数为调快，负数为调慢
+func ClockJump(prevWall time.Time, prevMono time.Duration, wall time.Time, mono time.Duration) time.Duration {
+	return wall.Sub(prevWall) - (mono - prevMono)
+}
+
+// FakeClock 用于测试的时钟，Advance模拟时间流逝，Set模拟修改系统时间
+type FakeClock struct {
+	mu      sync.Mutex
+	wall    time.Time
+	mono    time.Duration
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Duration
+	ch       chan time.Time
+}
+
+// NewFakeClock 创建墙上时间为now的测试时钟
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{wall: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.wall
+}
+
+func (c *FakeClock) Monotonic() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mono
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.wall
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.mono + d, ch: ch})
+	return ch
+}
+
+// Advance 时间流逝d，墙上时间和单调时间同时前进，到期的After返回
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mono += d
+	c.wall = c.wall.Add(d)
+
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline <= c.mono {
+			w.ch <- c.wall
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+	c.waiters = waiters
+}
+
+// Set 修改墙上时间，单调时间不变，等同于修改系统时间
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wall = t
+}
+
+// Waiters 正在等待的After数量
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil 阻塞到至少有n个After正在等待，用于测试中等待协程进入等待状态
+func (c *FakeClock) BlockUntil(n int) {
+	for c.Waiters() < n {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,235 @@
+package common
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/mzky/utils/internal/fsutil"
+)
+
+// Codec 二进制数据的文本编码方式
+type Codec int
+
+const (
+	CodecUnknown Codec = iota
+	CodecHex
+	CodecBase32
+	CodecBase64
+	CodecBase64URL
+	CodecBase58
+)
+
+// String 编码名称
+func (c Codec) String() string {
+	switch c {
+	case CodecHex:
+		return "hex"
+	case CodecBase32:
+		return "base32"
+	case CodecBase64:
+		return "base64"
+	case CodecBase64URL:
+		return "base64url"
+	case CodecBase58:
+		return "base58"
+	default:
+		return "unknown"
+	}
+}
+
+// Encode 按编码方式编码，base32、base64带填充
+func (c Codec) Encode(src []byte) (string, error) {
+	switch c {
+	case CodecHex:
+		return hex.EncodeToString(src), nil
+	case CodecBase32:
+		return base32.StdEncoding.EncodeToString(src), nil
+	case CodecBase64:
+		return base64.StdEncoding.EncodeToString(src), nil
+	case CodecBase64URL:
+		return base64.URLEncoding.EncodeToString(src), nil
+	case CodecBase58:
+		return Base58Encode(src), nil
+	default:
+		return "", errors.New("未知的编码方式")
+	}
+}
+
+// Decode 按编码方式解码，忽略空白，填充可有可无
+func (c Codec) Decode(s string) ([]byte, error) {
+	switch c {
+	case CodecHex:
+		return HexDecode(s)
+	case CodecBase32:
+		return Base32Decode(s)
+	case CodecBase64:
+		return Base64Decode(s)
+	case CodecBase64URL:
+		return Base64URLDecode(s)
+	case CodecBase58:
+		return Base58Decode(s)
+	default:
+		return nil, errors.New("未知的编码方式")
+	}
+}
+
+// Base64URLEncode URL安全的base64编码，带填充
+func Base64URLEncode(src []byte) string {
+	return base64.URLEncoding.EncodeToString(src)
+}
+
+// Base64RawURLEncode URL安全的base64编码，不带填充，常用于JWT等
+func Base64RawURLEncode(src []byte) string {
+	return base64.RawURLEncoding.EncodeToString(src)
+}
+
+// Base64RawEncode 标准base64编码，不带填充
+func Base64RawEncode(src []byte) string {
+	return base64.RawStdEncoding.EncodeToString(src)
+}
+
+// Base64URLDecode URL安全的base64解码，填充可有可无
+func Base64URLDecode(src string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(
+		strings.TrimRight(StripUnprintable(src), "="))
+}
+
+// Base32Encode 标准base32编码，带填充
+func Base32Encode(src []byte) string {
+	return base32.StdEncoding.EncodeToString(src)
+}
+
+// Base32Decode 标准base32解码，不区分大小写，填充可有可无
+func Base32Decode(src string) ([]byte, error) {
+	return base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(
+		strings.ToUpper(strings.TrimRight(StripUnprintable(src), "=")))
+}
+
+// HexEncode 小写十六进制编码
+func HexEncode(src []byte) string {
+	return hex.EncodeToString(src)
+}
+
+// HexDecode 十六进制解码，不区分大小写，忽略0x前缀、空白和":"、"-"分隔符
+func HexDecode(src string) ([]byte, error) {
+	s := strings.TrimPrefix(strings.TrimPrefix(StripUnprintable(src), "0x"), "0X")
+	s = strings.NewReplacer(":", "", "-", "").Replace(s)
+	return hex.DecodeString(s)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Base58Encode base58编码，使用比特币字母表，前导0字节编码为1
+func Base58Encode(src []byte) string {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(src)
+	base, mod := big.NewInt(58), new(big.Int)
+	out := make([]byte, 0, len(src)*138/100+1)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// Base58Decode base58解码，使用比特币字母表
+func Base58Decode(src string) ([]byte, error) {
+	s := StripUnprintable(src)
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+
+	n, base := new(big.Int), big.NewInt(58)
+	for i := zeros; i < len(s); i++ {
+		v := strings.IndexByte(base58Alphabet, s[i])
+		if v < 0 {
+			return nil, fmt.Errorf("非法的base58字符 %q", s[i])
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(v)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// NewEncoder 流式编码，写入的数据编码后写到w，必须调用Close写出剩余数据，base58不支持流式
+func NewEncoder(c Codec, w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case CodecHex:
+		return fsutil.NopWriteCloser{Writer: hex.NewEncoder(w)}, nil
+	case CodecBase32:
+		return base32.NewEncoder(base32.StdEncoding, w), nil
+	case CodecBase64:
+		return base64.NewEncoder(base64.StdEncoding, w), nil
+	case CodecBase64URL:
+		return base64.NewEncoder(base64.URLEncoding, w), nil
+	default:
+		return nil, fmt.Errorf("%s不支持流式编码", c)
+	}
+}
+
+// NewDecoder 流式解码，从r读取编码后的文本，base32、base64会忽略换行，base58不支持流式
+func NewDecoder(c Codec, r io.Reader) (io.Reader, error) {
+	switch c {
+	case CodecHex:
+		return hex.NewDecoder(r), nil
+	case CodecBase32:
+		return base32.NewDecoder(base32.StdEncoding, r), nil
+	case CodecBase64:
+		return base64.NewDecoder(base64.StdEncoding, r), nil
+	case CodecBase64URL:
+		return base64.NewDecoder(base64.URLEncoding, r), nil
+	default:
+		return nil, fmt.Errorf("%s不支持流式解码", c)
+	}
+}
+
+// AutoDecode 依次尝试hex、base32、base58、base64url/base64解码，返回解码结果及匹配的编码方式
+// 同一字符串可能同时符合多种编码(如"abcd")，结果仅为最可能的一种
+func AutoDecode(src string) ([]byte, Codec, error) {
+	s := StripUnprintable(src)
+	if s == "" {
+		return nil, CodecUnknown, errors.New("内容为空")
+	}
+
+	candidates := make([]Codec, 0, 5)
+	trimmed := strings.TrimRight(s, "=")
+	if h := strings.TrimPrefix(s, "0x"); strings.Trim(h, "0123456789abcdefABCDEF") == "" && len(h)%2 == 0 {
+		candidates = append(candidates, CodecHex)
+	}
+	if strings.Trim(trimmed, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") == "" && (len(s)%8 == 0 || s == trimmed) {
+		candidates = append(candidates, CodecBase32)
+	}
+	// 长度不是4的倍数又全是base58字符时，更可能是base58而不是无填充的base64
+	if len(s)%4 != 0 && strings.Trim(s, base58Alphabet) == "" {
+		candidates = append(candidates, CodecBase58)
+	}
+	if strings.ContainsAny(s, "-_") {
+		candidates = append(candidates, CodecBase64URL)
+	} else {
+		candidates = append(candidates, CodecBase64)
+	}
+	candidates = append(candidates, CodecBase58)
+
+	for _, c := range candidates {
+		if b, err := c.Decode(s); err == nil {
+			return b, c, nil
+		}
+	}
+	return nil, CodecUnknown, errors.New("无法识别的编码")
+}
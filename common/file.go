@@ -243,3 +243,40 @@ func IsFileName(fileName string) bool {
 
 	return true
 }
+
+// WriteFileAtomic 先写入同目录下的临时文件再重命名，避免写入中断导致文件损坏
+// 文件已存在时保留原有权限和属主(无权修改属主时忽略)，filename为符号链接时写入链接指向的文件，链接本身不变
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+	fi, err := os.Stat(filename)
+	if err == nil {
+		perm = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	if fi != nil {
+		chownLike(tmp.Name(), fi)
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package common
+
+import "os"
+
+// chownLike 非unix系统没有属主属组，不做处理
+func chownLike(string, os.FileInfo) {}
@@ -0,0 +1,36 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "sysctl.conf")
+	link := filepath.Join(dir, "sysctl.d", "99-sysctl.conf")
+	if err := os.WriteFile(target, []byte("a = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(link), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../sysctl.conf", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(link, []byte("a = 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("符号链接被替换: %v, %v", fi, err)
+	}
+	fi, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "a = 2\n" || fi.Mode().Perm() != 0600 {
+		t.Fatalf("target = %q %v", b, fi.Mode())
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package common
+
+import (
+	"os"
+	"syscall"
+)
+
+// chownLike 将fp的属主和属组设置为与fi相同，失败时忽略(非root用户通常无权修改属主)
+func chownLike(fp string, fi os.FileInfo) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		_ = os.Chown(fp, int(st.Uid), int(st.Gid))
+	}
+}
@@ -0,0 +1,201 @@
+package common
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 时间有序ID，同一进程内严格递增，系统时间回拨时沿用上次的时间戳继续递增，不会产生重复或倒序的ID
+
+var uuidV7 struct {
+	sync.Mutex
+	ms  int64
+	seq uint16 // 12位，占用rand_a
+}
+
+// NewUUIDv7 生成RFC 9562 UUIDv7，前48位为毫秒时间戳，同一毫秒内使用12位计数器保证递增
+func NewUUIDv7() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+
+	uuidV7.Lock()
+	ms := time.Now().UnixMilli()
+	if ms > uuidV7.ms {
+		uuidV7.ms = ms
+		uuidV7.seq = uint16(b[6]&0x07)<<8 | uint16(b[7]) // 计数器从随机的较小值开始，留出递增空间
+	} else {
+		uuidV7.seq++
+		if uuidV7.seq > 0x0fff {
+			uuidV7.ms++
+			uuidV7.seq = 0
+		}
+	}
+	ms, seq := uuidV7.ms, uuidV7.seq
+	uuidV7.Unlock()
+
+	b[0], b[1], b[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	b[3], b[4], b[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+	b[6] = 0x70 | byte(seq>>8)
+	b[7] = byte(seq)
+	b[8] = b[8]&0x3f | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
+
+// UUIDv7Time 解析UUIDv7中的时间戳
+func UUIDv7Time(id string) (time.Time, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(id, "-", ""))
+	if err != nil || len(b) != 16 {
+		return time.Time{}, fmt.Errorf("非法的UUID: %s", id)
+	}
+	if b[6]>>4 != 7 {
+		return time.Time{}, fmt.Errorf("不是UUIDv7: %s", id)
+	}
+	ms := int64(b[0])<<40 | int64(b[1])<<32 | int64(b[2])<<24 | int64(b[3])<<16 | int64(b[4])<<8 | int64(b[5])
+	return time.UnixMilli(ms), nil
+}
+
+// ULID使用的Crockford base32字母表，去掉了I、L、O、U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var ulid struct {
+	sync.Mutex
+	ms      int64
+	entropy [10]byte
+}
+
+// NewULID 生成26位ULID，前48位为毫秒时间戳，后80位随机，同一毫秒内随机部分递增
+func NewULID() string {
+	var b [16]byte
+
+	ulid.Lock()
+	ms := time.Now().UnixMilli()
+	if ms > ulid.ms {
+		ulid.ms = ms
+		_, _ = rand.Read(ulid.entropy[:])
+	} else if !increment(ulid.entropy[:]) {
+		// 随机部分溢出时借用下一毫秒
+		ulid.ms++
+		_, _ = rand.Read(ulid.entropy[:])
+	}
+	ms = ulid.ms
+	copy(b[6:], ulid.entropy[:])
+	ulid.Unlock()
+
+	b[0], b[1], b[2] = byte(ms>>40), byte(ms>>32), byte(ms>>24)
+	b[3], b[4], b[5] = byte(ms>>16), byte(ms>>8), byte(ms)
+
+	// 128位按5位一组编码，首字符只占3位
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := 25; i >= 0; i-- {
+		s[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+// ULIDTime 解析ULID中的时间戳，不区分大小写
+func ULIDTime(id string) (time.Time, error) {
+	if len(id) != 26 || strings.IndexByte("01234567", id[0]) < 0 {
+		return time.Time{}, fmt.Errorf("非法的ULID: %s", id)
+	}
+	var ms int64
+	for _, c := range strings.ToUpper(id[:10]) {
+		v := strings.IndexRune(crockford, c)
+		if v < 0 {
+			return time.Time{}, fmt.Errorf("非法的ULID: %s", id)
+		}
+		ms = ms<<5 | int64(v)
+	}
+	return time.UnixMilli(ms), nil
+}
+
+// increment 大端字节序加1，溢出时返回false
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	snowflakeWorkerBits = 10
+	snowflakeSeqBits    = 12
+	MaxSnowflakeWorker  = 1<<snowflakeWorkerBits - 1
+	maxSnowflakeSeq     = 1<<snowflakeSeqBits - 1
+)
+
+// SnowflakeEpoch 默认纪元，41位毫秒时间戳可使用约69年
+var SnowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Snowflake 雪花ID生成器，ID由41位毫秒时间戳、10位机器号、12位序列号组成
+type Snowflake struct {
+	mu     sync.Mutex
+	epoch  int64 // 纪元，毫秒
+	worker int64
+	last   int64 // 上次使用的时间戳，相对纪元
+	seq    int64
+}
+
+// NewSnowflake 创建雪花ID生成器，epoch为零值时使用SnowflakeEpoch，worker取值0~1023，也可使用net.NewSnowflakeByMAC按MAC地址分配
+// 多个进程同时生成ID时必须使用不同的worker
+func NewSnowflake(epoch time.Time, worker int64) (*Snowflake, error) {
+	if epoch.IsZero() {
+		epoch = SnowflakeEpoch
+	}
+	if epoch.After(time.Now()) {
+		return nil, errors.New("纪元不能晚于当前时间")
+	}
+	if worker < 0 || worker > MaxSnowflakeWorker {
+		return nil, fmt.Errorf("机器号超出范围0~%d: %d", MaxSnowflakeWorker, worker)
+	}
+	return &Snowflake{epoch: epoch.UnixMilli(), worker: worker}, nil
+}
+
+// Next 生成下一个ID，同一毫秒内序列号用尽或时间回拨时借用后续的时间戳，保证递增
+func (s *Snowflake) Next() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UnixMilli() - s.epoch
+	if now > s.last {
+		s.last = now
+		s.seq = 0
+	} else {
+		s.seq++
+		if s.seq > maxSnowflakeSeq {
+			s.last++
+			s.seq = 0
+		}
+	}
+	return s.last<<(snowflakeWorkerBits+snowflakeSeqBits) | s.worker<<snowflakeSeqBits | s.seq
+}
+
+// Parse 拆分ID中的时间、机器号和序列号
+func (s *Snowflake) Parse(id int64) (t time.Time, worker, seq int64) {
+	ms := id >> (snowflakeWorkerBits + snowflakeSeqBits)
+	worker = id >> snowflakeSeqBits & MaxSnowflakeWorker
+	seq = id & maxSnowflakeSeq
+	return time.UnixMilli(ms + s.epoch), worker, seq
+}
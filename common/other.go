@@ -3,6 +3,7 @@ package common
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
 	uuid "github.com/satori/go.uuid"
@@ -11,41 +12,20 @@ import (
 	"strings"
 )
 
+// GetUUID 生成随机的UUIDv4，用作数据库主键等需要时间有序的场景请使用NewUUIDv7、NewULID或Snowflake
 func GetUUID() string {
 	str := fmt.Sprintf("%s", uuid.Must(uuid.NewV4()))
 	return str
 }
 
 // GetMaxValue 获取最大值
-func GetMaxValue(numVal []int) (int, error) {
-	if len(numVal) < 1 {
-		return 0, errors.New("数组长度不能为0")
-	}
-
-	maxVal := numVal[0]
-	for _, v := range numVal {
-		if v > maxVal {
-			maxVal = v
-		}
-	}
-
-	return maxVal, nil
+func GetMaxValue[T cmp.Ordered](numVal []T) (T, error) {
+	return Max(numVal)
 }
 
 // GetMinValue 获取最小值
-func GetMinValue(numVal []int) (int, error) {
-	if len(numVal) < 1 {
-		return 0, errors.New("数组长度不能为0")
-	}
-
-	minVal := numVal[0]
-	for _, v := range numVal {
-		if v < minVal {
-			minVal = v
-		}
-	}
-
-	return minVal, nil
+func GetMinValue[T cmp.Ordered](numVal []T) (T, error) {
+	return Min(numVal)
 }
 
 // ReadLine 读取指定行的内容 0行开始
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// Levenshtein 编辑距离，按字符计算，汉字算1个
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// DamerauLevenshtein 编辑距离，相邻字符交换算1次编辑(OSA算法)
+func DamerauLevenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// Similarity 基于编辑距离的相似度，范围0-1，1为完全相同
+func Similarity(a, b string) float64 {
+	n := max(Len(a), Len(b))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(n)
+}
+
+// Jaro Jaro相似度，范围0-1
+func Jaro(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	window := max(len(ra), len(rb))/2 - 1
+	window = max(window, 0)
+	ma := make([]bool, len(ra))
+	mb := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !mb[j] && ra[i] == rb[j] {
+				ma[i], mb[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, j := 0, 0
+	for i := range ra {
+		if !ma[i] {
+			continue
+		}
+		for !mb[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	return (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// JaroWinkler Jaro-Winkler相似度，范围0-1，相同前缀(最多4个字符)会提高得分
+func JaroWinkler(a, b string) float64 {
+	j := Jaro(a, b)
+	prefix := min(Len(LongestCommonPrefix(a, b)), 4)
+	return j + float64(prefix)*0.1*(1-j)
+}
+
+// LongestCommonPrefix 最长公共前缀，按字符比较
+func LongestCommonPrefix(strs ...string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	prefix := []rune(strs[0])
+	for _, s := range strs[1:] {
+		r := []rune(s)
+		n := 0
+		for n < len(prefix) && n < len(r) && prefix[n] == r[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return string(prefix)
+}
+
+// LongestCommonSubstring 最长公共子串，按字符比较，长度相同时返回在a中最先出现的
+func LongestCommonSubstring(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	best, end := 0, 0
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			if ra[i-1] == rb[j-1] {
+				cur[j] = prev[j-1] + 1
+				if cur[j] > best {
+					best, end = cur[j], i
+				}
+			} else {
+				cur[j] = 0
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return string(ra[end-best : end])
+}
+
+// FuzzyMatch 模糊搜索结果
+type FuzzyMatch struct {
+	Str   string
+	Index int     // 在候选列表中的下标
+	Score float64 // 范围0-1
+}
+
+// FuzzySearch 在candidates中模糊搜索query，忽略大小写，返回得分不低于minScore的结果，按得分从高到低排序
+// 包含query的候选得分不低于0.9，其余按Jaro-Winkler相似度计分
+func FuzzySearch(query string, candidates []string, minScore float64) []FuzzyMatch {
+	q := strings.ToLower(query)
+	matches := make([]FuzzyMatch, 0)
+	for i, c := range candidates {
+		lc := strings.ToLower(c)
+		score := JaroWinkler(q, lc)
+		if q != "" && strings.Contains(lc, q) {
+			score = max(score, 0.9+0.1*float64(Len(q))/float64(Len(lc)))
+		}
+		if score >= minScore {
+			matches = append(matches, FuzzyMatch{Str: c, Index: i, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
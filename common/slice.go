@@ -0,0 +1,139 @@
+package common
+
+import (
+	"cmp"
+	"errors"
+)
+
+// Contains s中是否存在v，精确匹配
+func Contains[T comparable](s []T, v T) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Unique 去除重复元素，保留首次出现的顺序，不修改s
+func Unique[T comparable](s []T) []T {
+	seen := make(map[T]struct{}, len(s))
+	ret := make([]T, 0, len(s))
+	for _, v := range s {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Diff 返回在a中但不在b中的元素，已去重，保持a中的顺序
+func Diff[T comparable](a, b []T) []T {
+	set := toSet(b)
+	ret := make([]T, 0)
+	for _, v := range Unique(a) {
+		if _, ok := set[v]; !ok {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Intersect 返回同时在a和b中的元素，已去重，保持a中的顺序
+func Intersect[T comparable](a, b []T) []T {
+	set := toSet(b)
+	ret := make([]T, 0)
+	for _, v := range Unique(a) {
+		if _, ok := set[v]; ok {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Union 返回a和b的并集，已去重，先a后b保持顺序
+func Union[T comparable](a, b []T) []T {
+	ret := make([]T, 0, len(a)+len(b))
+	ret = append(ret, a...)
+	return Unique(append(ret, b...))
+}
+
+// Chunk 按size切分，最后一块可能不足size，size<=0时返回nil
+func Chunk[T any](s []T, size int) [][]T {
+	if size <= 0 {
+		return nil
+	}
+	ret := make([][]T, 0, (len(s)+size-1)/size)
+	for size < len(s) {
+		s, ret = s[size:], append(ret, s[:size:size])
+	}
+	if len(s) > 0 {
+		ret = append(ret, s)
+	}
+	return ret
+}
+
+// GroupBy 按key函数的返回值分组，组内保持原顺序
+func GroupBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	ret := make(map[K][]T)
+	for _, v := range s {
+		k := key(v)
+		ret[k] = append(ret[k], v)
+	}
+	return ret
+}
+
+// Map 将每个元素转换为新的值
+func Map[T, R any](s []T, fn func(T) R) []R {
+	ret := make([]R, 0, len(s))
+	for _, v := range s {
+		ret = append(ret, fn(v))
+	}
+	return ret
+}
+
+// Filter 保留fn返回true的元素，不修改s
+func Filter[T any](s []T, fn func(T) bool) []T {
+	ret := make([]T, 0)
+	for _, v := range s {
+		if fn(v) {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+// Max 获取最大值，s为空时返回错误
+func Max[T cmp.Ordered](s []T) (T, error) {
+	if len(s) < 1 {
+		var zero T
+		return zero, errors.New("数组长度不能为0")
+	}
+	maxVal := s[0]
+	for _, v := range s[1:] {
+		maxVal = max(maxVal, v)
+	}
+	return maxVal, nil
+}
+
+// Min 获取最小值，s为空时返回错误
+func Min[T cmp.Ordered](s []T) (T, error) {
+	if len(s) < 1 {
+		var zero T
+		return zero, errors.New("数组长度不能为0")
+	}
+	minVal := s[0]
+	for _, v := range s[1:] {
+		minVal = min(minVal, v)
+	}
+	return minVal, nil
+}
+
+func toSet[T comparable](s []T) map[T]struct{} {
+	set := make(map[T]struct{}, len(s))
+	for _, v := range s {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -38,7 +38,8 @@ func NotValue(input, seq, defaultVal string) string {
 	return input
 }
 
-// StringPrefixEqualCount 判断连续相同的字符串个数
+// StringPrefixEqualCount 统计两个字符串相同位置上字节相等的个数，并非公共前缀长度
+// 公共前缀请使用LongestCommonPrefix，相似度比较请使用Levenshtein、JaroWinkler等
 func StringPrefixEqualCount(str1, str2 string) int {
 	len1 := len(str1)
 	len2 := len(str2)
@@ -4,15 +4,27 @@ import "time"
 
 // StartTimer cycle 天之后的 hour 点执行
 func StartTimer(f func(), cycle time.Duration, hour int) {
+	StartTimerWithClock(SystemClock, f, cycle, hour)
+}
+
+// StartTimerWithClock 使用指定时钟的StartTimer
+// 每次最多等待1秒后重新读取墙上时间，修改系统时间后按新的时间判断是否到期，不会因长定时器而提前或推迟执行
+func StartTimerWithClock(c Clock, f func(), cycle time.Duration, hour int) {
 	go func() {
 		for {
-			now := time.Now()
+			now := c.Now()
 			next := now.Add(time.Hour * 24 * cycle)
 			next = time.Date(next.Year(), next.Month(), next.Day(), hour, 0, 0, 0, next.Location())
 			// 测试代码，可以设置几分钟生成一个文件
 			// next = time.Date(next.Year(), now.Month(), now.Day(), now.Hour(), now.Minute()+hour, 0, 0, next.Location())
-			t := time.NewTimer(next.Sub(now))
-			<-t.C
+			for now.Before(next) {
+				wait := next.Sub(now)
+				if wait > time.Second {
+					wait = time.Second
+				}
+				<-c.After(wait)
+				now = c.Now()
+			}
 			f()
 		}
 	}()
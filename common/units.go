@@ -0,0 +1,229 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/spf13/viper"
+)
+
+// Size 字节数，配置中可写为"10M"、"1.5GiB"、"500KB"等
+type Size int64
+
+// String 格式化为IEC单位，如10MiB
+func (s Size) String() string {
+	return FormatSize(int64(s))
+}
+
+// MarshalText 实现encoding.TextMarshaler
+func (s Size) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText 实现encoding.TextUnmarshaler
+func (s *Size) UnmarshalText(text []byte) error {
+	n, err := ParseSize(string(text))
+	if err != nil {
+		return err
+	}
+	*s = Size(n)
+	return nil
+}
+
+var sizeRegexp = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+// ParseSize 解析字节数，不区分大小写
+// K、M、G、T、P及KiB、MiB等为1024进制，KB、MB等为1000进制，无单位或B为字节
+func ParseSize(s string) (int64, error) {
+	m := sizeRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("无法解析大小 %q", s)
+	}
+	num, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析大小 %q", s)
+	}
+
+	unit := strings.ToUpper(m[2])
+	base := 1024.0
+	switch {
+	case unit == "" || unit == "B":
+		unit = "B"
+	case strings.HasSuffix(unit, "IB"):
+		unit = strings.TrimSuffix(unit, "IB")
+	case len(unit) == 2 && strings.HasSuffix(unit, "B"):
+		unit, base = unit[:1], 1000
+	}
+	exp := strings.Index("BKMGTP", unit)
+	if exp < 0 || len(unit) != 1 {
+		return 0, fmt.Errorf("无法识别的大小单位 %q", m[2])
+	}
+
+	n := num * math.Pow(base, float64(exp))
+	if n >= math.MaxInt64 {
+		return 0, fmt.Errorf("大小 %q 超出范围", s)
+	}
+	return int64(n), nil
+}
+
+// FormatSize 格式化为1024进制，如10MiB、1.5GiB，最多保留两位小数
+func FormatSize(n int64) string {
+	return formatSize(n, 1024, "iB")
+}
+
+// FormatSizeSI 格式化为1000进制，如10MB、1.5GB，最多保留两位小数
+func FormatSizeSI(n int64) string {
+	return formatSize(n, 1000, "B")
+}
+
+func formatSize(n int64, base float64, suffix string) string {
+	f := float64(n)
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	if f < base {
+		return fmt.Sprintf("%s%dB", sign, int64(f))
+	}
+	exp := 0
+	for f >= base && exp < 5 {
+		f /= base
+		exp++
+	}
+	num := strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+	return sign + num + "KMGTP"[exp-1:exp] + suffix
+}
+
+var durationRegexp = regexp.MustCompile(`([0-9]+)(?:\.([0-9]+))?(ns|us|µs|ms|s|m|h|d|w)`)
+
+var durationUnits = map[string]uint64{
+	"ns": uint64(time.Nanosecond),
+	"us": uint64(time.Microsecond),
+	"µs": uint64(time.Microsecond),
+	"ms": uint64(time.Millisecond),
+	"s":  uint64(time.Second),
+	"m":  uint64(time.Minute),
+	"h":  uint64(time.Hour),
+	"d":  uint64(24 * time.Hour),
+	"w":  uint64(7 * 24 * time.Hour),
+}
+
+// ParseDuration 在time.ParseDuration的基础上支持d(天)和w(周)，如"1095d"、"1w2d12h"
+func ParseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	neg := strings.HasPrefix(str, "-")
+	str = strings.TrimLeft(str, "+-")
+	if str == "0" {
+		return 0, nil
+	}
+	if str == "" || durationRegexp.ReplaceAllString(str, "") != "" {
+		return 0, fmt.Errorf("无法解析时长 %q", s)
+	}
+
+	// 按整数纳秒累加并检查溢出，负数可以达到math.MinInt64
+	limit := uint64(math.MaxInt64)
+	if neg {
+		limit++
+	}
+	var total uint64
+	for _, m := range durationRegexp.FindAllStringSubmatch(str, -1) {
+		unit := durationUnits[m[3]]
+		n, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil || n > limit/unit {
+			return 0, fmt.Errorf("时长 %q 超出范围", s)
+		}
+		v := n*unit + fraction(m[2], unit)
+		if v > limit || total > limit-v {
+			return 0, fmt.Errorf("时长 %q 超出范围", s)
+		}
+		total += v
+	}
+	if neg {
+		return -time.Duration(total), nil
+	}
+	return time.Duration(total), nil
+}
+
+// fraction 小数部分对应的纳秒数，与time.ParseDuration一样舍去不足1ns的部分
+func fraction(digits string, unit uint64) uint64 {
+	var f uint64
+	scale := 1.0
+	for _, c := range digits {
+		if f > (math.MaxInt64-9)/10 {
+			break // 超出精度的位数忽略
+		}
+		f = f*10 + uint64(c-'0')
+		scale *= 10
+	}
+	return uint64(float64(f) * (float64(unit) / scale))
+}
+
+// FormatDuration 格式化时长，超过一天时使用d，省略为0的单位，如"1095d"、"1d2h30m"
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	day := 24 * time.Hour
+	var b strings.Builder
+	b.WriteString(sign)
+	if d >= day {
+		b.WriteString(strconv.FormatInt(int64(d/day), 10) + "d")
+		d %= day
+	}
+	if d >= time.Second {
+		for _, u := range []struct {
+			d    time.Duration
+			name string
+		}{{time.Hour, "h"}, {time.Minute, "m"}} {
+			if d >= u.d {
+				b.WriteString(strconv.FormatInt(int64(d/u.d), 10) + u.name)
+				d %= u.d
+			}
+		}
+	}
+	if d > 0 {
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+// StringToSizeHookFunc viper/mapstructure解码钩子，将字符串转换为Size
+func StringToSizeHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(Size(0)) {
+			return data, nil
+		}
+		n, err := ParseSize(data.(string))
+		return Size(n), err
+	}
+}
+
+// StringToDurationHookFunc viper/mapstructure解码钩子，将字符串转换为time.Duration，支持d和w
+func StringToDurationHookFunc() mapstructure.DecodeHookFuncType {
+	return func(f reflect.Type, t reflect.Type, data any) (any, error) {
+		if f.Kind() != reflect.String || t != reflect.TypeOf(time.Duration(0)) {
+			return data, nil
+		}
+		return ParseDuration(data.(string))
+	}
+}
+
+// ViperDecodeHook 用于viper.Unmarshal，在viper默认钩子的基础上支持Size和带天、周的time.Duration
+// 如 v.Unmarshal(&cfg, common.ViperDecodeHook())
+func ViperDecodeHook() viper.DecoderConfigOption {
+	return viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		StringToSizeHookFunc(),
+		StringToDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	))
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"1K", 1024},
+		{"1k", 1024},
+		{"1KiB", 1024},
+		{"1KB", 1000},
+		{"1.5M", 3 << 19},
+		{"10 MiB", 10 << 20},
+		{"2GB", 2e9},
+		{"1T", 1 << 40},
+		{"1P", 1 << 50},
+		{"4095P", 4095 << 50},
+	}
+	for _, tt := range tests {
+		if got, err := ParseSize(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "abc", "-1K", "1X", "1KiBB", "1.K", "8192P"} {
+		if got, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want error", in, got)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		in      int64
+		iec, si string
+	}{
+		{0, "0B", "0B"},
+		{999, "999B", "999B"},
+		{1024, "1KiB", "1.02KB"},
+		{1536, "1.5KiB", "1.54KB"},
+		{-10 << 20, "-10MiB", "-10.49MB"},
+		{1 << 50, "1PiB", "1.13PB"},
+		{math.MaxInt64, "8192PiB", "9223.37PB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.in); got != tt.iec {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.in, got, tt.iec)
+		}
+		if got := FormatSizeSI(tt.in); got != tt.si {
+			t.Errorf("FormatSizeSI(%d) = %q, want %q", tt.in, got, tt.si)
+		}
+	}
+	for _, n := range []int64{0, 1, 1023, 1 << 10, 10 << 20, 3 << 30, 1 << 50} {
+		if got, err := ParseSize(FormatSize(n)); err != nil || got != n {
+			t.Errorf("ParseSize(FormatSize(%d)) = %d, %v", n, got, err)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"0", 0},
+		{"1.5h", 90 * time.Minute},
+		{"1w2d12h", 9*24*time.Hour + 12*time.Hour},
+		{"-1d", -24 * time.Hour},
+		{"+2m", 2 * time.Minute},
+		{"1.5ns", 1},
+		{"300µs", 300 * time.Microsecond},
+		{"1095d1ns", 1095*24*time.Hour + 1},
+		{"2562047h47m16.854775807s", math.MaxInt64},
+		{"-2562047h47m16.854775808s", math.MinInt64},
+		{"15250w1d23h47m16.854775807s", math.MaxInt64},
+	}
+	for _, tt := range tests {
+		if got, err := ParseDuration(tt.in); err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "1", "1x", "d", "1.d", "2562047h47m16.854775808s", "15251w", "99999999999999999999ns", "106752d"} {
+		if got, err := ParseDuration(in); err == nil {
+			t.Errorf("ParseDuration(%q) = %v, want error", in, got)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1500 * time.Millisecond, "1.5s"},
+		{90 * time.Minute, "1h30m"},
+		{1095 * 24 * time.Hour, "1095d"},
+		{26*time.Hour + 30*time.Minute, "1d2h30m"},
+		{-time.Hour, "-1h"},
+		{time.Minute + 1, "1m1ns"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.in); got != tt.want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	for _, d := range []time.Duration{1, time.Second + 1, 1095*24*time.Hour + 1, 25*time.Hour + 500*time.Millisecond, math.MaxInt64, math.MinInt64 + 1, -36 * time.Hour} {
+		if got, err := ParseDuration(FormatDuration(d)); err != nil || got != d {
+			t.Errorf("ParseDuration(FormatDuration(%d)) = %d, %v", d, got, err)
+		}
+	}
+}
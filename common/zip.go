@@ -1,12 +1,19 @@
 package common
 
 import (
+	stdzip "archive/zip"
 	"bytes"
+	"compress/flate"
 	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"time"
 
+	"github.com/mzky/utils/internal/fsutil"
 	"github.com/mzky/zip"
 )
 
@@ -22,88 +29,606 @@ func IsZip(zipPath string) bool {
 		return false
 	}
 
-	return bytes.Equal(buf, []byte("PK\x03\x04"))
+	// 空压缩包只有目录结束记录
+	return bytes.Equal(buf, []byte("PK\x03\x04")) || bytes.Equal(buf, []byte("PK\x05\x06"))
 }
 
-// Zip password值可以为空""
+// ZipEncryption zip加密方式
+type ZipEncryption int
+
+const (
+	ZipAES256 ZipEncryption = iota // 默认，Windows资源管理器无法解压
+	ZipAES192
+	ZipAES128
+	ZipCrypto // 传统加密，安全性较低，兼容Windows资源管理器
+)
+
+// String 加密方式名称
+func (e ZipEncryption) String() string {
+	switch e {
+	case ZipAES256:
+		return "AES-256"
+	case ZipAES192:
+		return "AES-192"
+	case ZipAES128:
+		return "AES-128"
+	case ZipCrypto:
+		return "ZipCrypto"
+	default:
+		return "unknown"
+	}
+}
+
+func (e ZipEncryption) method() zip.EncryptionMethod {
+	switch e {
+	case ZipAES192:
+		return zip.AES192Encryption
+	case ZipAES128:
+		return zip.AES128Encryption
+	case ZipCrypto:
+		return zip.StandardEncryption
+	default:
+		return zip.AES256Encryption
+	}
+}
+
+// ZipOptions 压缩选项
+type ZipOptions struct {
+	// Password 为空时不加密
+	Password string
+	// Encryption 加密方式，默认AES-256
+	Encryption ZipEncryption
+	// BaseDir 包内路径为相对BaseDir的路径，为空时以各文件所在目录为基准
+	BaseDir string
+	// Exclude 排除规则，使用path.Match匹配包内路径或文件名，匹配的目录整体排除
+	Exclude []string
+	// Level 压缩级别1-9，0为默认级别，mzky/zip的压缩级别固定，加密时不生效
+	Level int
+	// Store 仅存储不压缩，mzky/zip的ZipCrypto仅支持压缩，此时不生效
+	Store bool
+	// ModTime 不为零值时统一设置条目的修改时间，否则使用文件自身的修改时间
+	ModTime time.Time
+	// Progress 进度回调，written为已写入的文件字节数，total为文件总字节数
+	Progress func(name string, written, total int64)
+}
+
+// Zip password值可以为空""，包内路径为相对各文件所在目录的路径，目录会递归加入
 func Zip(zipPath, password string, fileList []string) error {
+	return ZipWithOptions(zipPath, fileList, ZipOptions{Password: password})
+}
+
+// ZipWithOptions 按选项压缩文件和目录，失败时删除未完成的压缩文件
+func ZipWithOptions(zipPath string, fileList []string, opts ZipOptions) error {
 	fz, err := os.Create(zipPath)
 	if err != nil {
 		return err
 	}
-	defer fz.Close()
-	zw := zip.NewWriter(fz)
-	defer zw.Close()
+	if err = ZipTo(fz, fileList, opts); err != nil {
+		_ = fz.Close()
+		_ = os.Remove(zipPath)
+		return err
+	}
+	return fz.Close()
+}
 
-	for _, fileName := range fileList {
-		fr, errA := os.Open(fileName)
-		if errA != nil {
-			return errA
+// ZipTo 按选项压缩文件和目录并写入w，可直接写入http响应
+func ZipTo(w io.Writer, fileList []string, opts ZipOptions) error {
+	entries, total, err := zipEntries(fileList, opts)
+	if err != nil {
+		return err
+	}
+
+	zw := newZipWriter(w, opts)
+	var written int64
+	for _, e := range entries {
+		if err = zw.add(e, opts, func(n int64) {
+			written += n
+			if opts.Progress != nil {
+				opts.Progress(e.name, written, total)
+			}
+		}); err != nil {
+			_ = zw.Close()
+			return err
 		}
+	}
+	return zw.Close()
+}
+
+// zipEntry 待压缩的文件或目录
+type zipEntry struct {
+	path string
+	name string
+	info os.FileInfo
+}
 
-		// 写入文件的头信息
-		var w io.Writer
-		var errB error
-		if password != "" {
-			w, errB = zw.Encrypt(fileName, password, zip.AES256Encryption)
+// zipEntries 遍历fileList，返回待压缩条目和文件总字节数
+// fileList中直接给出的符号链接会跟随到目标文件，目录中的符号链接和其他特殊文件被跳过
+// 不同来源的文件在包内重名时返回错误，同名目录合并
+func zipEntries(fileList []string, opts ZipOptions) ([]zipEntry, int64, error) {
+	top := make(map[string]bool, len(fileList))
+	for _, file := range fileList {
+		top[file] = true
+	}
+	var entries []zipEntry
+	var total int64
+	seen := make(map[string]bool) // 已加入的包内路径，值为是否是目录
+	err := fsutil.Walk(opts.BaseDir, fileList, func(fp, name string, fi os.FileInfo) error {
+		if zipExcluded(name, opts.Exclude) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if top[fp] && fi.Mode()&os.ModeSymlink != 0 {
+			st, err := os.Stat(fp)
+			if err != nil {
+				return err
+			}
+			if !st.Mode().IsRegular() {
+				return fmt.Errorf("%s 不是普通文件的符号链接，无法压缩", fp)
+			}
+			fi = st
+		}
+		if !fi.IsDir() && !fi.Mode().IsRegular() {
+			if top[fp] {
+				return fmt.Errorf("%s 不是普通文件或目录，无法压缩", fp)
+			}
+			return nil
+		}
+		if isDir, ok := seen[name]; ok {
+			if isDir && fi.IsDir() {
+				return nil
+			}
+			return fmt.Errorf("%s 在压缩包内重名: %s", fp, name)
+		}
+		seen[name] = fi.IsDir()
+		if fi.IsDir() {
+			name += "/"
 		} else {
-			w, errB = zw.Create(fileName)
+			total += fi.Size()
 		}
+		entries = append(entries, zipEntry{path: fp, name: name, info: fi})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
 
-		if errB != nil {
-			return errB
+func zipExcluded(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, path.Base(name)); ok {
+			return true
 		}
+	}
+	return false
+}
 
-		// 写入文件内容
-		_, errC := io.Copy(w, fr)
-		if errC != nil {
-			return errC
+// zipWriter 不加密时使用标准库以支持压缩级别，加密时使用mzky/zip
+type zipWriter struct {
+	std *stdzip.Writer
+	enc *zip.Writer
+}
+
+func newZipWriter(w io.Writer, opts ZipOptions) *zipWriter {
+	if opts.Password != "" {
+		return &zipWriter{enc: zip.NewWriter(w)}
+	}
+	zw := stdzip.NewWriter(w)
+	level := opts.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+	zw.RegisterCompressor(stdzip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+	return &zipWriter{std: zw}
+}
+
+// add 写入一个条目，onWrite在每次写入文件内容后回调
+func (zw *zipWriter) add(e zipEntry, opts ZipOptions, onWrite func(n int64)) error {
+	modTime := opts.ModTime
+	if modTime.IsZero() {
+		modTime = e.info.ModTime()
+	}
+	method := zip.Deflate
+	// mzky/zip的ZipCrypto写入时不支持仅存储
+	store := opts.Store && !(opts.Password != "" && opts.Encryption == ZipCrypto)
+	if store || e.info.IsDir() {
+		method = zip.Store
+	}
+
+	var w io.Writer
+	var err error
+	if zw.std != nil {
+		fh := &stdzip.FileHeader{Name: e.name, Method: method, Modified: modTime}
+		fh.SetMode(e.info.Mode())
+		w, err = zw.std.CreateHeader(fh)
+	} else {
+		fh := &zip.FileHeader{Name: e.name, Method: method}
+		fh.SetModTime(modTime)
+		fh.SetMode(e.info.Mode())
+		if !e.info.IsDir() {
+			fh.SetPassword(opts.Password)
+			fh.SetEncryptionMethod(opts.Encryption.method())
 		}
-		_ = fr.Close()
+		w, err = zw.enc.CreateHeader(fh)
+	}
+	if err != nil || e.info.IsDir() {
+		return err
+	}
+
+	fr, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	defer fr.Close()
+
+	_, err = io.Copy(progressWriter{w: w, onWrite: onWrite}, fr)
+	return err
+}
+
+func (zw *zipWriter) Close() error {
+	if zw.std != nil {
+		return zw.std.Close()
+	}
+	return zw.enc.Close()
+}
+
+type progressWriter struct {
+	w       io.Writer
+	onWrite func(n int64)
+}
+
+func (p progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.onWrite(int64(n))
+	return n, err
+}
+
+// 解压错误类别，可使用errors.Is判断
+var (
+	ErrZipPassword = errors.New("解压密码不正确")
+	ErrZipCorrupt  = errors.New("压缩文件格式不正确或已损坏")
+	ErrZipPolicy   = errors.New("压缩文件违反解压限制")
+)
+
+// ZipEntryError 解压错误，Kind为ErrZipPassword、ErrZipCorrupt或ErrZipPolicy之一
+type ZipEntryError struct {
+	Name string // 出错的条目，为空时表示整个压缩文件
+	Kind error
+	Err  error // 具体原因
+}
+
+func (e *ZipEntryError) Error() string {
+	if e.Name == "" {
+		return fmt.Sprintf("%v: %v", e.Kind, e.Err)
 	}
-	return zw.Flush()
+	return fmt.Sprintf("%s: %v: %v", e.Name, e.Kind, e.Err)
+}
+
+func (e *ZipEntryError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// SymlinkPolicy 解压时符号链接的处理方式
+type SymlinkPolicy int
+
+const (
+	SymlinkSkip   SymlinkPolicy = iota // 忽略符号链接
+	SymlinkSafe                        // 仅创建指向解压目录内的符号链接，否则报错
+	SymlinkReject                      // 遇到符号链接即报错
+)
+
+// UnZipOptions 解压选项，数值限制为0时不限制
+type UnZipOptions struct {
+	// Password 加密条目使用的密码，未加密的条目直接解压
+	Password string
+	// MaxTotalSize 解压后的总字节数上限
+	MaxTotalSize int64
+	// MaxRatio 单个条目解压后与压缩后字节数的比例上限
+	MaxRatio int64
+	// MaxEntries 条目数上限
+	MaxEntries int
+	// Symlinks 符号链接的处理方式
+	Symlinks SymlinkPolicy
 }
 
 // UnZip password值可以为空""
 // 当decompressPath值为"./"时，解压到相对路径
 func UnZip(zipPath, password, decompressPath string) error {
-	if !FileIsExist(zipPath) {
-		return errors.New("找不到压缩文件")
+	return UnZipWithOptions(zipPath, decompressPath, UnZipOptions{Password: password})
+}
+
+// UnZipWithOptions 按选项解压，条目路径不允许逃逸出decompressPath
+func UnZipWithOptions(zipPath, decompressPath string, opts UnZipOptions) error {
+	fz, r, err := openZip(zipPath)
+	if err != nil {
+		return err
 	}
-	if !IsZip(zipPath) {
-		return errors.New("压缩文件格式不正确或已损坏")
+	defer fz.Close()
+	return unZip(fz, r, decompressPath, opts)
+}
+
+// UnZipReader 按选项解压从ra读取的压缩数据，size为数据长度
+func UnZipReader(ra io.ReaderAt, size int64, decompressPath string, opts UnZipOptions) error {
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		return &ZipEntryError{Kind: ErrZipCorrupt, Err: err}
 	}
-	r, err := zip.OpenReader(zipPath)
+	return unZip(ra, r, decompressPath, opts)
+}
+
+func unZip(ra io.ReaderAt, r *zip.Reader, decompressPath string, opts UnZipOptions) error {
+	if opts.MaxEntries > 0 && len(r.File) > opts.MaxEntries {
+		return &ZipEntryError{Kind: ErrZipPolicy, Err: fmt.Errorf("条目数 %d 超过上限 %d", len(r.File), opts.MaxEntries)}
+	}
+
+	dest, err := filepath.Abs(decompressPath)
 	if err != nil {
 		return err
 	}
-	defer r.Close()
+	var total int64
+	for _, f := range r.File {
+		fp, err := fsutil.Join(dest, f.Name)
+		if err != nil {
+			return &ZipEntryError{Name: f.Name, Kind: ErrZipPolicy, Err: err}
+		}
+		if f.FileInfo().IsDir() {
+			if err = os.MkdirAll(fp, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if err = zipSetPassword(ra, f, opts.Password); err != nil {
+			return err
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			if err = unZipSymlink(f, dest, fp, opts.Symlinks); err != nil {
+				return err
+			}
+			continue
+		}
+		n, err := unZipFile(f, fp, opts, total)
+		total += n
+		if err != nil {
+			_ = os.Remove(fp)
+			return err
+		}
+	}
+	return nil
+}
+
+// ZipEntryInfo 压缩包条目信息
+type ZipEntryInfo struct {
+	Name           string
+	Size           int64 // 解压后的字节数
+	CompressedSize int64 // 压缩后的字节数，AES加密时包含加密头和校验码
+	IsDir          bool
+	Encrypted      bool
+	Encryption     ZipEncryption // 仅Encrypted为true时有效
+	ModTime        time.Time
+}
 
+// ZipList 列出压缩包中的条目，不解压
+func ZipList(zipPath string) ([]ZipEntryInfo, error) {
+	fz, r, err := openZip(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fz.Close()
+
+	list := make([]ZipEntryInfo, 0, len(r.File))
 	for _, f := range r.File {
-		if password != "" {
-			if f.IsEncrypted() {
-				f.SetPassword(password)
-			} else {
-				return errors.New("must be encrypted")
+		info := ZipEntryInfo{
+			Name:           f.Name,
+			Size:           int64(f.UncompressedSize64),
+			CompressedSize: int64(f.CompressedSize64),
+			IsDir:          f.FileInfo().IsDir(),
+			Encrypted:      f.IsEncrypted(),
+			ModTime:        f.ModTime(),
+		}
+		if info.Encrypted {
+			switch zipAESStrength(f.Extra) {
+			case 0:
+				info.Encryption = ZipCrypto
+			case 1:
+				info.Encryption = ZipAES128
+			case 2:
+				info.Encryption = ZipAES192
+			default:
+				info.Encryption = ZipAES256
 			}
 		}
+		list = append(list, info)
+	}
+	return list, nil
+}
 
-		fp := filepath.Join(decompressPath, f.Name)
-		_ = os.MkdirAll(filepath.Dir(fp), os.ModePerm)
+// UnZipEntry 将名为name的条目解压写入w，password值可以为空""
+func UnZipEntry(zipPath, password, name string, w io.Writer) error {
+	fz, r, err := openZip(zipPath)
+	if err != nil {
+		return err
+	}
+	defer fz.Close()
 
-		w, errA := os.Create(fp)
-		if errA != nil {
-			return errors.New("无法创建解压文件")
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			return fmt.Errorf("条目 %s 是目录", name)
+		}
+		if err = zipSetPassword(fz, f, password); err != nil {
+			return err
 		}
-		fr, errB := f.Open()
-		if errB != nil {
-			return errors.New("解压密码不正确")
+		fr, err := f.Open()
+		if err != nil {
+			return zipOpenError(f, err)
 		}
-		if _, errC := io.Copy(w, fr); errC != nil {
-			return errC
+		defer fr.Close()
+		if _, err = io.Copy(w, fr); err != nil {
+			return zipOpenError(f, err)
 		}
-		_ = fr.Close()
-		_ = w.Close()
+		return nil
+	}
+	return fmt.Errorf("条目 %s: %w", name, os.ErrNotExist)
+}
+
+// openZip 打开压缩文件，返回的*os.File需要调用方关闭
+func openZip(zipPath string) (*os.File, *zip.Reader, error) {
+	if !FileIsExist(zipPath) {
+		return nil, nil, errors.New("找不到压缩文件")
+	}
+	if !IsZip(zipPath) {
+		return nil, nil, &ZipEntryError{Kind: ErrZipCorrupt, Err: errors.New("不是zip文件")}
+	}
+	fz, err := os.Open(zipPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := fz.Stat()
+	if err != nil {
+		_ = fz.Close()
+		return nil, nil, err
+	}
+	r, err := zip.NewReader(fz, fi.Size())
+	if err != nil {
+		_ = fz.Close()
+		return nil, nil, &ZipEntryError{Kind: ErrZipCorrupt, Err: err}
+	}
+	return fz, r, nil
+}
+
+// zipSetPassword 为加密条目设置密码，并校验ZipCrypto密码
+func zipSetPassword(ra io.ReaderAt, f *zip.File, password string) error {
+	if !f.IsEncrypted() {
+		return nil
+	}
+	if password == "" {
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipPassword, Err: errors.New("条目已加密，需要密码")}
+	}
+	f.SetPassword(password)
+	if zipAESStrength(f.Extra) != 0 {
+		return nil // AES在打开时校验密码
+	}
+
+	// ZipCrypto加密头12字节，解密后最后一个字节为校验字节
+	offset, err := f.DataOffset()
+	if err != nil {
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipCorrupt, Err: err}
+	}
+	head := make([]byte, 12)
+	if _, err = ra.ReadAt(head, offset); err != nil {
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipCorrupt, Err: err}
+	}
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&0x8 != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if zip.NewZipCrypto([]byte(password)).Decrypt(head)[11] != check {
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipPassword, Err: zip.ErrPassword}
 	}
 	return nil
 }
+
+// zipAESStrength 从扩展字段中读取WinZip AES强度，1-3分别为AES-128/192/256，非AES加密返回0
+func zipAESStrength(extra []byte) byte {
+	for len(extra) >= 4 {
+		tag := uint16(extra[0]) | uint16(extra[1])<<8
+		size := int(uint16(extra[2]) | uint16(extra[3])<<8)
+		extra = extra[4:]
+		if size > len(extra) {
+			return 0
+		}
+		if tag == 0x9901 && size >= 7 {
+			return extra[4]
+		}
+		extra = extra[size:]
+	}
+	return 0
+}
+
+// zipOpenError 区分打开条目时的密码错误与文件损坏
+func zipOpenError(f *zip.File, err error) error {
+	if errors.Is(err, zip.ErrPassword) {
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipPassword, Err: err}
+	}
+	return &ZipEntryError{Name: f.Name, Kind: ErrZipCorrupt, Err: err}
+}
+
+// unZipFile 解压单个文件，按实际写入的字节数检查大小限制，返回写入的字节数
+func unZipFile(f *zip.File, fp string, opts UnZipOptions, total int64) (int64, error) {
+	limit := int64(-1)
+	if opts.MaxTotalSize > 0 {
+		limit = opts.MaxTotalSize - total
+	}
+	if opts.MaxRatio > 0 {
+		ratioLimit := int64(f.CompressedSize64) * opts.MaxRatio
+		if limit < 0 || ratioLimit < limit {
+			limit = ratioLimit
+		}
+	}
+
+	fr, err := f.Open()
+	if err != nil {
+		return 0, zipOpenError(f, err)
+	}
+	defer fr.Close()
+
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	var src io.Reader = fr
+	if limit >= 0 {
+		src = io.LimitReader(fr, limit+1)
+	}
+	n, err := fsutil.WriteFile(fp, src, mode)
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return n, fmt.Errorf("无法写入解压文件 %s: %w", fp, err)
+	}
+	if err != nil {
+		if f.IsEncrypted() && zipAESStrength(f.Extra) == 0 {
+			// ZipCrypto只有1字节校验，约1/256的错误密码能通过校验，解压时才出错
+			return n, &ZipEntryError{Name: f.Name, Kind: ErrZipPassword, Err: err}
+		}
+		return n, zipOpenError(f, err)
+	}
+	if limit >= 0 && n > limit {
+		return n, &ZipEntryError{Name: f.Name, Kind: ErrZipPolicy, Err: errors.New("解压后大小超过限制")}
+	}
+	return n, nil
+}
+
+// unZipSymlink 按策略处理符号链接条目，条目内容为链接目标
+func unZipSymlink(f *zip.File, dest, fp string, policy SymlinkPolicy) error {
+	switch policy {
+	case SymlinkSkip:
+		return nil
+	case SymlinkReject:
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipPolicy, Err: errors.New("不允许符号链接")}
+	}
+
+	fr, err := f.Open()
+	if err != nil {
+		return zipOpenError(f, err)
+	}
+	defer fr.Close()
+	target, err := io.ReadAll(io.LimitReader(fr, 4096))
+	if err != nil {
+		return zipOpenError(f, err)
+	}
+
+	err = fsutil.Symlink(dest, fp, string(target))
+	if errors.Is(err, fsutil.ErrUnsafePath) {
+		return &ZipEntryError{Name: f.Name, Kind: ErrZipPolicy, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,226 @@
+package common
+
+import (
+	stdzip "archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testZipEntry 测试用的zip条目，link不为空时为符号链接
+type testZipEntry struct {
+	name, link, body string
+}
+
+func makeZip(t *testing.T, entries ...testZipEntry) string {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zw := stdzip.NewWriter(buf)
+	for _, e := range entries {
+		fh := &stdzip.FileHeader{Name: e.name, Method: stdzip.Deflate}
+		body := e.body
+		if e.link != "" {
+			fh.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		} else {
+			fh.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	fp := filepath.Join(t.TempDir(), "test.zip")
+	if err := os.WriteFile(fp, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fp
+}
+
+func TestUnZipEscape(t *testing.T) {
+	cases := map[string][]testZipEntry{
+		"dotdot":   {{name: "../evil.txt", body: "x"}},
+		"nested":   {{name: "a/../../evil.txt", body: "x"}},
+		"absolute": {{name: "/evil.txt", body: "x"}},
+		"link abs": {{name: "l1", link: "/"}, {name: "l1/evil.txt", body: "x"}},
+		"link up":  {{name: "l1", link: ".."}, {name: "l1/evil.txt", body: "x"}},
+		"chain":    {{name: "l1", link: "."}, {name: "l1/l2", link: ".."}, {name: "l2/evil.txt", body: "x"}},
+		"via link": {{name: "l1", link: "."}, {name: "l2", link: "l1/.."}, {name: "l2/evil.txt", body: "x"}},
+	}
+	for name, entries := range cases {
+		t.Run(name, func(t *testing.T) {
+			zipPath := makeZip(t, entries...)
+			parent := t.TempDir()
+			err := UnZipWithOptions(zipPath, filepath.Join(parent, "dest"), UnZipOptions{Symlinks: SymlinkSafe})
+			if !errors.Is(err, ErrZipPolicy) {
+				t.Fatalf("err = %v, want ErrZipPolicy", err)
+			}
+			if _, err := os.Stat(filepath.Join(parent, "evil.txt")); err == nil {
+				t.Fatal("文件被写到解压目录之外")
+			}
+		})
+	}
+}
+
+func TestUnZipSymlinks(t *testing.T) {
+	zipPath := makeZip(t, testZipEntry{name: "a/b.txt", body: "x"}, testZipEntry{name: "c", link: "a/b.txt"})
+
+	dest := t.TempDir()
+	if err := UnZipWithOptions(zipPath, dest, UnZipOptions{Symlinks: SymlinkSkip}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "c")); err == nil {
+		t.Fatal("SymlinkSkip时不应创建符号链接")
+	}
+
+	dest = t.TempDir()
+	if err := UnZipWithOptions(zipPath, dest, UnZipOptions{Symlinks: SymlinkSafe}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dest, "c")); err != nil || string(b) != "x" {
+		t.Fatalf("content = %q, %v", b, err)
+	}
+
+	err := UnZipWithOptions(zipPath, t.TempDir(), UnZipOptions{Symlinks: SymlinkReject})
+	if !errors.Is(err, ErrZipPolicy) {
+		t.Fatalf("err = %v, want ErrZipPolicy", err)
+	}
+}
+
+func TestUnZipLimits(t *testing.T) {
+	zeros := string(make([]byte, 1<<20))
+	zipPath := makeZip(t,
+		testZipEntry{name: "a.txt", body: "0123456789"},
+		testZipEntry{name: "b.txt", body: "0123456789"},
+		testZipEntry{name: "zeros", body: zeros},
+	)
+
+	cases := map[string]UnZipOptions{
+		"MaxEntries":   {MaxEntries: 2},
+		"MaxTotalSize": {MaxTotalSize: 15},
+		"MaxRatio":     {MaxRatio: 100},
+	}
+	for name, opts := range cases {
+		err := UnZipWithOptions(zipPath, t.TempDir(), opts)
+		if !errors.Is(err, ErrZipPolicy) {
+			t.Fatalf("%s: err = %v, want ErrZipPolicy", name, err)
+		}
+	}
+
+	opts := UnZipOptions{MaxEntries: 3, MaxTotalSize: 1<<20 + 20, MaxRatio: 2000}
+	if err := UnZipWithOptions(zipPath, t.TempDir(), opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnZipErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(src, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, enc := range []ZipEncryption{ZipAES256, ZipCrypto} {
+		zipPath := filepath.Join(dir, enc.String()+".zip")
+		if err := ZipWithOptions(zipPath, []string{src}, ZipOptions{Password: "secret", Encryption: enc}); err != nil {
+			t.Fatal(err)
+		}
+		for _, password := range []string{"", "wrong"} {
+			err := UnZip(zipPath, password, t.TempDir())
+			var ee *ZipEntryError
+			if !errors.Is(err, ErrZipPassword) || !errors.As(err, &ee) || ee.Name != "a.txt" {
+				t.Fatalf("%s password %q: err = %v, want ErrZipPassword", enc, password, err)
+			}
+		}
+		dest := t.TempDir()
+		if err := UnZip(zipPath, "secret", dest); err != nil {
+			t.Fatal(err)
+		}
+		if b, err := os.ReadFile(filepath.Join(dest, "a.txt")); err != nil || string(b) != "hello" {
+			t.Fatalf("content = %q, %v", b, err)
+		}
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.zip")
+	if err := os.WriteFile(corrupt, []byte("PK\x03\x04 not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnZip(corrupt, "", t.TempDir()); !errors.Is(err, ErrZipCorrupt) {
+		t.Fatalf("err = %v, want ErrZipCorrupt", err)
+	}
+}
+
+func TestZipInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/x.txt", "b/x.txt", "c/sub/y.txt", "d/sub/z.txt"} {
+		fp := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fp, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(filepath.Join(dir, "a", "x.txt"), link); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "a"), filepath.Join(dir, "dirlink")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 直接给出的符号链接压缩目标文件的内容
+	zipPath := filepath.Join(dir, "link.zip")
+	if err := Zip(zipPath, "", []string{link}); err != nil {
+		t.Fatal(err)
+	}
+	dest := t.TempDir()
+	if err := UnZip(zipPath, "", dest); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(filepath.Join(dest, "link.txt")); err != nil || string(b) != "a/x.txt" {
+		t.Fatalf("content = %q, %v", b, err)
+	}
+
+	if err := Zip(filepath.Join(dir, "dirlink.zip"), "", []string{filepath.Join(dir, "dirlink")}); err == nil {
+		t.Error("指向目录的符号链接应返回错误")
+	}
+	if err := Zip(filepath.Join(dir, "dup.zip"), "", []string{filepath.Join(dir, "a", "x.txt"), filepath.Join(dir, "b", "x.txt")}); err == nil {
+		t.Error("包内重名应返回错误")
+	}
+
+	// 同名目录合并
+	zipPath = filepath.Join(dir, "merge.zip")
+	if err := Zip(zipPath, "", []string{filepath.Join(dir, "c", "sub"), filepath.Join(dir, "d", "sub")}); err != nil {
+		t.Fatal(err)
+	}
+	dest = t.TempDir()
+	if err := UnZip(zipPath, "", dest); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sub/y.txt", "sub/z.txt"} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// 空压缩包
+	zipPath = filepath.Join(dir, "empty.zip")
+	if err := Zip(zipPath, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !IsZip(zipPath) {
+		t.Error("空压缩包应识别为zip")
+	}
+	if err := UnZip(zipPath, "", t.TempDir()); err != nil {
+		t.Error(err)
+	}
+}
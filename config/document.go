@@ -0,0 +1,347 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mzky/utils/common"
+)
+
+// ErrInvalid Set的section、键或值无法原样写入文件，如包含换行符
+var ErrInvalid = errors.New("无效的配置项")
+
+// syntax 不同格式的解析规则
+type syntax struct {
+	comments  string // 注释起始字符
+	sections  bool   // 是否支持[section]
+	shell     bool   // 值使用shell引号规则，支持export前缀
+	sysctl    bool   // 键中的/等同于.，支持-前缀忽略错误
+	separator string // 新增键时使用的分隔符
+	inline    bool   // 支持行尾注释，空白后的注释字符及之后的内容不属于值
+}
+
+// line 文件中的一行，键值行拆分为 prefix+value+suffix 以便原样保留空白和注释
+type line struct {
+	raw     string
+	section string // 所属section，全局为""
+	header  bool   // 是否为[section]行
+	key     string // 非键值行为空
+	prefix  string // 缩进、键、分隔符及其两侧空白
+	value   string // 原始值(未去引号)
+	suffix  string // 值之后的空白和注释
+}
+
+// document 按行保存文件内容，修改时只改动涉及的行
+type document struct {
+	path    string
+	syntax  syntax
+	lines   []*line
+	eol     string
+	trailer bool // 文件末尾是否有换行
+}
+
+func load(path string, s syntax) (*document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d := parse(data, s)
+	d.path = path
+	return d, nil
+}
+
+func parse(data []byte, s syntax) *document {
+	d := &document{syntax: s, eol: "\n"}
+	text := string(data)
+	if strings.Contains(text, "\r\n") {
+		d.eol = "\r\n"
+	}
+	d.trailer = text == "" || strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return d
+	}
+
+	section := ""
+	for _, raw := range strings.Split(text, "\n") {
+		l := d.parseLine(strings.TrimSuffix(raw, "\r"), section)
+		if l.header {
+			section = l.section
+		}
+		d.lines = append(d.lines, l)
+	}
+	return d
+}
+
+func (d *document) parseLine(raw, section string) *line {
+	l := &line{raw: raw, section: section}
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || strings.ContainsAny(trimmed[:1], d.syntax.comments) {
+		return l
+	}
+	if d.syntax.sections && strings.HasPrefix(trimmed, "[") {
+		// [b] ; comment 去掉行尾注释后再判断
+		header := strings.TrimRight(trimmed[:d.commentAt(trimmed)], " \t")
+		if strings.HasSuffix(header, "]") {
+			l.header = true
+			l.section = strings.TrimSpace(header[1 : len(header)-1])
+			return l
+		}
+	}
+
+	eq := strings.Index(raw, "=")
+	if eq < 0 {
+		return l
+	}
+	key := strings.TrimSpace(raw[:eq])
+	if d.syntax.shell {
+		key = strings.TrimSpace(strings.TrimPrefix(key, "export "))
+	}
+	if d.syntax.sysctl {
+		key = strings.TrimPrefix(key, "-")
+	}
+	if key == "" {
+		return l
+	}
+
+	start := eq + 1
+	for start < len(raw) && (raw[start] == ' ' || raw[start] == '\t') {
+		start++
+	}
+	end := len(strings.TrimRight(raw[:d.commentAt(raw)], " \t"))
+	if d.syntax.shell {
+		end = start + shellValueLen(raw[start:])
+	}
+	if end < start {
+		end = start
+	}
+	l.key = d.normalize(key)
+	l.prefix = raw[:start]
+	l.value = raw[start:end]
+	l.suffix = raw[end:]
+	return l
+}
+
+// commentAt 返回行尾注释的起始位置，注释字符前须为空白，没有行尾注释时返回len(s)
+func (d *document) commentAt(s string) int {
+	if !d.syntax.inline {
+		return len(s)
+	}
+	for i := 1; i < len(s); i++ {
+		if strings.IndexByte(d.syntax.comments, s[i]) >= 0 && (s[i-1] == ' ' || s[i-1] == '\t') {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// normalize 统一键的写法，sysctl中 net/ipv4/ip_forward 等同于 net.ipv4.ip_forward
+func (d *document) normalize(key string) string {
+	if d.syntax.sysctl {
+		return strings.ReplaceAll(key, "/", ".")
+	}
+	return key
+}
+
+// find 查找键所在的行，重复的键以最后一个为准
+func (d *document) find(section, key string) int {
+	key = d.normalize(key)
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		l := d.lines[i]
+		if l.key == key && l.section == section && !l.header {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *document) get(section, key string) (string, bool) {
+	i := d.find(section, key)
+	if i < 0 {
+		return "", false
+	}
+	v := d.lines[i].value
+	if d.syntax.shell {
+		return shellUnquote(v), true
+	}
+	return v, true
+}
+
+func (d *document) set(section, key, value string) error {
+	if err := d.check(section, key, value); err != nil {
+		return err
+	}
+	if i := d.find(section, key); i >= 0 {
+		l := d.lines[i]
+		if d.syntax.shell {
+			value = shellQuote(value, l.value)
+		}
+		l.value = value
+		l.raw = l.prefix + l.value + l.suffix
+		return nil
+	}
+
+	if d.syntax.shell {
+		value = shellQuote(value, "")
+	}
+	l := d.parseLine(key+d.separator(section)+value, section)
+	d.insert(section, l)
+	return nil
+}
+
+// check 检查写入的内容重新读取时能否得到相同的section、键和值，避免换行等字符改变文件结构
+func (d *document) check(section, key, value string) error {
+	if strings.ContainsAny(section+key+value, "\r\n") {
+		return fmt.Errorf("%w: 不能包含换行符", ErrInvalid)
+	}
+	if strings.ContainsRune(section, ']') || strings.TrimSpace(section) != section {
+		return fmt.Errorf("%w: section %q", ErrInvalid, section)
+	}
+
+	invalidKey := key == "" || strings.TrimSpace(key) != key || strings.ContainsRune(key, '=') ||
+		strings.ContainsAny(key[:1], d.syntax.comments+"[")
+	switch {
+	case d.syntax.shell:
+		invalidKey = invalidKey || !isShellName(key)
+	case d.syntax.sysctl:
+		invalidKey = invalidKey || key[0] == '-'
+	}
+	if invalidKey {
+		return fmt.Errorf("%w: 键 %q", ErrInvalid, key)
+	}
+
+	if d.syntax.shell {
+		return nil // 由shellQuote加引号
+	}
+	if strings.TrimSpace(value) != value {
+		return fmt.Errorf("%w: 值 %q 首尾不能有空白", ErrInvalid, value)
+	}
+	if d.syntax.inline && (d.commentAt(value) < len(value) || value != "" && strings.ContainsAny(value[:1], d.syntax.comments)) {
+		return fmt.Errorf("%w: 值 %q 会被读作注释", ErrInvalid, value)
+	}
+	return nil
+}
+
+// separator 新增键时沿用文件中已有的分隔符写法，优先使用同一section中的写法
+func (d *document) separator(section string) string {
+	sep := ""
+	for _, l := range d.lines {
+		if l.key == "" {
+			continue
+		}
+		if l.section == section {
+			return sepOf(l.prefix)
+		}
+		if sep == "" {
+			sep = sepOf(l.prefix)
+		}
+	}
+	if sep == "" {
+		return d.syntax.separator
+	}
+	return sep
+}
+
+// sepOf 提取"key = "中的" = "部分
+func sepOf(prefix string) string {
+	eq := strings.Index(prefix, "=")
+	left := strings.TrimRight(prefix[:eq], " \t")
+	return prefix[len(left):]
+}
+
+// insert 在section的最后一个键值之后插入，section不存在时在文件末尾新建
+func (d *document) insert(section string, l *line) {
+	last := -1
+	for i, x := range d.lines {
+		if x.section != section {
+			continue
+		}
+		if x.key != "" || x.header {
+			last = i
+		}
+	}
+	if last < 0 && section == "" {
+		// 全局键放在第一个section之前
+		for i, x := range d.lines {
+			if x.header {
+				last = i - 1
+				for last >= 0 && strings.TrimSpace(d.lines[last].raw) == "" {
+					last--
+				}
+				break
+			}
+			last = i
+		}
+	}
+	if last < 0 && section != "" {
+		if n := len(d.lines); n > 0 && strings.TrimSpace(d.lines[n-1].raw) != "" {
+			d.lines = append(d.lines, &line{section: d.lastSection()})
+		}
+		d.lines = append(d.lines, &line{raw: "[" + section + "]", section: section, header: true})
+		last = len(d.lines) - 1
+	}
+
+	d.lines = append(d.lines, nil)
+	copy(d.lines[last+2:], d.lines[last+1:])
+	d.lines[last+1] = l
+}
+
+func (d *document) lastSection() string {
+	if n := len(d.lines); n > 0 {
+		return d.lines[n-1].section
+	}
+	return ""
+}
+
+// remove 删除section中所有同名的键
+func (d *document) remove(section, key string) bool {
+	key = d.normalize(key)
+	removed := false
+	lines := d.lines[:0]
+	for _, l := range d.lines {
+		if l.key == key && l.section == section && !l.header {
+			removed = true
+			continue
+		}
+		lines = append(lines, l)
+	}
+	d.lines = lines
+	return removed
+}
+
+// keys 按出现顺序返回section中的键，重复的键只返回一次
+func (d *document) keys(section string) []string {
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, l := range d.lines {
+		if l.key != "" && l.section == section && !l.header && !seen[l.key] {
+			seen[l.key] = true
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Bytes 返回修改后的文件内容
+func (d *document) Bytes() []byte {
+	var b strings.Builder
+	for i, l := range d.lines {
+		b.WriteString(l.raw)
+		if i < len(d.lines)-1 || d.trailer {
+			b.WriteString(d.eol)
+		}
+	}
+	return []byte(b.String())
+}
+
+// Save 原子写回加载时的文件
+func (d *document) Save() error {
+	return d.SaveAs(d.path)
+}
+
+// SaveAs 原子写入指定文件
+func (d *document) SaveAs(path string) error {
+	return common.WriteFileAtomic(path, d.Bytes(), 0644)
+}
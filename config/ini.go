@@ -0,0 +1,84 @@
+package config
+
+// INI 保留注释、顺序和空白的INI文件编辑器，section为""表示第一个section之前的全局键
+type INI struct {
+	*document
+}
+
+var iniSyntax = syntax{comments: ";#", sections: true, separator: " = ", inline: true}
+
+// LoadINI 读取INI文件
+func LoadINI(path string) (*INI, error) {
+	d, err := load(path, iniSyntax)
+	if err != nil {
+		return nil, err
+	}
+	return &INI{d}, nil
+}
+
+// ParseINI 解析INI内容，Save前需要使用SaveAs指定文件
+func ParseINI(data []byte) *INI {
+	return &INI{parse(data, iniSyntax)}
+}
+
+// Get 获取键值，键重复时以最后一个为准
+func (f *INI) Get(section, key string) (string, bool) {
+	return f.get(section, key)
+}
+
+// Set 设置键值，键不存在时追加到section末尾，section不存在时新建
+// 值包含换行符、首尾空白或会被读作行尾注释(如"a ;b")时返回ErrInvalid
+func (f *INI) Set(section, key, value string) error {
+	return f.set(section, key, value)
+}
+
+// Delete 删除section中的键
+func (f *INI) Delete(section, key string) bool {
+	return f.remove(section, key)
+}
+
+// Keys 按出现顺序返回section中的键
+func (f *INI) Keys(section string) []string {
+	return f.keys(section)
+}
+
+// Sections 按出现顺序返回所有section，不包含全局section
+func (f *INI) Sections() []string {
+	sections := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, l := range f.lines {
+		if l.header && !seen[l.section] {
+			seen[l.section] = true
+			sections = append(sections, l.section)
+		}
+	}
+	return sections
+}
+
+// HasSection section是否存在
+func (f *INI) HasSection(section string) bool {
+	for _, l := range f.lines {
+		if l.header && l.section == section {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteSection 删除section及其中的所有行
+func (f *INI) DeleteSection(section string) bool {
+	if section == "" {
+		return false
+	}
+	removed := false
+	lines := f.lines[:0]
+	for _, l := range f.lines {
+		if l.section == section {
+			removed = true
+			continue
+		}
+		lines = append(lines, l)
+	}
+	f.lines = lines
+	return removed
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestINIComments(t *testing.T) {
+	f := ParseINI([]byte("[a]\nx = 1 ; note\nurl = http://h/#frag\n[b] ; comment\ny = 2 # note\n"))
+	for _, tt := range []struct{ section, key, want string }{
+		{"a", "x", "1"},
+		{"a", "url", "http://h/#frag"},
+		{"b", "y", "2"},
+	} {
+		if v, ok := f.Get(tt.section, tt.key); !ok || v != tt.want {
+			t.Errorf("Get(%q, %q) = %q, %v, want %q", tt.section, tt.key, v, ok, tt.want)
+		}
+	}
+	if _, ok := f.Get("a", "y"); ok {
+		t.Error("[b] ; comment 未识别为section")
+	}
+
+	f.Set("b", "y", "3")
+	f.Set("a", "x", "5")
+	want := "[a]\nx = 5 ; note\nurl = http://h/#frag\n[b] ; comment\ny = 3 # note\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
+
+func TestINISetInvalid(t *testing.T) {
+	data := "[a]\nx = 1\n"
+	f := ParseINI([]byte(data))
+	for _, tt := range []struct{ section, key, value string }{
+		{"a", "y", "2\n[evil]\nz=3"},
+		{"a", "y", "2\r"},
+		{"a\n[evil", "y", "2"},
+		{"a]", "y", "2"},
+		{"a", "y\nz", "2"},
+		{"a", "", "2"},
+		{"a", "y=z", "2"},
+		{"a", "; y", "2"},
+		{"a", "[y", "2"},
+		{"a", "y", "2 ; 3"},
+		{"a", "y", "2\t#3"},
+		{"a", "y", "#2"},
+		{"a", "y", " 2"},
+	} {
+		if err := f.Set(tt.section, tt.key, tt.value); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Set(%q, %q, %q) err = %v, want ErrInvalid", tt.section, tt.key, tt.value, err)
+		}
+	}
+	if got := string(f.Bytes()); got != data {
+		t.Errorf("Bytes() = %q, want %q", got, data)
+	}
+
+	// 不会被读作注释的值可以写入
+	for _, v := range []string{"2;3", "a#b", "http://h/#frag", ""} {
+		if err := f.Set("b", "y", v); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := ParseINI(f.Bytes()).Get("b", "y"); got != v {
+			t.Errorf("Set(%q) 重新读取为 %q", v, got)
+		}
+	}
+	if got := ParseINI(f.Bytes()).Sections(); len(got) != 2 {
+		t.Errorf("Sections() = %v", got)
+	}
+}
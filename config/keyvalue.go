@@ -0,0 +1,61 @@
+package config
+
+// KeyValue 保留注释、顺序和空白的key=value文件编辑器
+type KeyValue struct {
+	*document
+}
+
+var (
+	// shell变量文件，如/etc/sysconfig/*、/etc/default/grub、/etc/os-release
+	shellSyntax = syntax{comments: "#", shell: true, separator: "="}
+	// sysctl.conf
+	sysctlSyntax = syntax{comments: "#;", sysctl: true, separator: " = "}
+)
+
+// LoadKeyValue 读取shell风格的key=value文件，值支持单双引号和转义，允许export前缀
+func LoadKeyValue(path string) (*KeyValue, error) {
+	d, err := load(path, shellSyntax)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyValue{d}, nil
+}
+
+// ParseKeyValue 解析shell风格的key=value内容
+func ParseKeyValue(data []byte) *KeyValue {
+	return &KeyValue{parse(data, shellSyntax)}
+}
+
+// LoadSysctl 读取sysctl.conf格式的文件，键中的/与.等价
+func LoadSysctl(path string) (*KeyValue, error) {
+	d, err := load(path, sysctlSyntax)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyValue{d}, nil
+}
+
+// ParseSysctl 解析sysctl.conf格式的内容
+func ParseSysctl(data []byte) *KeyValue {
+	return &KeyValue{parse(data, sysctlSyntax)}
+}
+
+// Get 获取键值，已去除引号，键重复时以最后一个为准
+func (f *KeyValue) Get(key string) (string, bool) {
+	return f.get("", key)
+}
+
+// Set 设置键值，必要时自动加引号，键不存在时追加，键或值包含换行符时返回ErrInvalid
+func (f *KeyValue) Set(key, value string) error {
+	return f.set("", key, value)
+}
+
+// Delete 删除键
+func (f *KeyValue) Delete(key string) bool {
+	return f.remove("", key)
+}
+
+// Keys 按出现顺序返回所有键
+func (f *KeyValue) Keys() []string {
+	return f.keys("")
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyValueQuote(t *testing.T) {
+	f := ParseKeyValue([]byte(`# comment
+A=plain
+B="hello world"  # note
+export C='single $x'
+D=esc\ aped
+E="q\"uote \$HOME \n"
+F=
+`))
+	for _, tt := range []struct{ key, want string }{
+		{"A", "plain"},
+		{"B", "hello world"},
+		{"C", "single $x"},
+		{"D", "esc aped"},
+		{"E", `q"uote $HOME \n`},
+		{"F", ""},
+	} {
+		if v, ok := f.Get(tt.key); !ok || v != tt.want {
+			t.Errorf("Get(%q) = %q, %v, want %q", tt.key, v, ok, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ key, value, line string }{
+		{"A", "a b", `A="a b"`},
+		{"B", "x", `B="x"  # note`},
+		{"C", "$y", `export C='$y'`},
+		{"C", "it's", `export C="it's"`},
+		{"G", `a"b$c`, `G="a\"b\$c"`},
+		{"H", "/usr/bin:/bin", `H=/usr/bin:/bin`},
+		{"I", "", `I=""`},
+	} {
+		if err := f.Set(tt.key, tt.value); err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := ParseKeyValue(f.Bytes()).Get(tt.key); v != tt.value {
+			t.Errorf("Set(%q, %q) 重新读取为 %q", tt.key, tt.value, v)
+		}
+		if l := f.lines[f.find("", tt.key)].raw; l != tt.line {
+			t.Errorf("Set(%q, %q) 写入 %q, want %q", tt.key, tt.value, l, tt.line)
+		}
+	}
+
+	for _, tt := range []struct{ key, value string }{
+		{"A", "1\nB=2"},
+		{"A", "1\r"},
+		{"1A", "1"},
+		{"A B", "1"},
+		{"export A", "1"},
+		{"A-B", "1"},
+	} {
+		if err := f.Set(tt.key, tt.value); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Set(%q, %q) err = %v, want ErrInvalid", tt.key, tt.value, err)
+		}
+	}
+}
+
+func TestSysctl(t *testing.T) {
+	f := ParseSysctl([]byte("; comment\nnet.ipv4.ip_forward = 0\n-kernel/yama/ptrace_scope = 1\nvm.swappiness=10 # not a comment\n"))
+	for _, tt := range []struct{ key, want string }{
+		{"net.ipv4.ip_forward", "0"},
+		{"net/ipv4/ip_forward", "0"},
+		{"kernel.yama.ptrace_scope", "1"},
+		{"vm.swappiness", "10 # not a comment"},
+	} {
+		if v, ok := f.Get(tt.key); !ok || v != tt.want {
+			t.Errorf("Get(%q) = %q, %v, want %q", tt.key, v, ok, tt.want)
+		}
+	}
+
+	if err := f.Set("net/ipv4/ip_forward", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("kernel.yama.ptrace_scope", "2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("fs.file-max", "65535"); err != nil {
+		t.Fatal(err)
+	}
+	want := "; comment\nnet.ipv4.ip_forward = 1\n-kernel/yama/ptrace_scope = 2\nvm.swappiness=10 # not a comment\nfs.file-max = 65535\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+
+	for _, tt := range []struct{ key, value string }{
+		{"vm.swappiness", "1\nkernel.x = 2"},
+		{"-vm.swappiness", "1"},
+		{"# vm.x", "1"},
+		{"vm.x", " 1"},
+	} {
+		if err := f.Set(tt.key, tt.value); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Set(%q, %q) err = %v, want ErrInvalid", tt.key, tt.value, err)
+		}
+	}
+	if !f.Delete("net/ipv4/ip_forward") || f.Delete("net.ipv4.ip_forward") {
+		t.Error("Delete应按规范化后的键删除一次")
+	}
+}
@@ -0,0 +1,359 @@
+package convert
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mzky/utils/common"
+)
+
+var (
+	ErrOverflow    = errors.New("数值超出目标类型范围")
+	ErrUnsupported = errors.New("不支持的类型转换")
+)
+
+// 字符串转时间时依次尝试的格式
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	time.DateTime,
+	"2006-01-02 15:04",
+	time.DateOnly,
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"20060102150405",
+	"20060102",
+}
+
+// indirect 解引用指针，nil指针返回无效的reflect.Value
+func indirect(v any) reflect.Value {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+func unsupported(v any, to string) error {
+	return fmt.Errorf("%w: %T 转换为 %s", ErrUnsupported, v, to)
+}
+
+// ToInt64 转换为int64，浮点数截断小数部分，字符串支持0x、0o、0b进制前缀和小数，前导0按十进制处理("010"为10)
+func ToInt64(v any) (int64, error) {
+	rv := indirect(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %d 转换为 int64", ErrOverflow, u)
+		}
+		return int64(u), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+			return 0, fmt.Errorf("%w: %v 转换为 int64", ErrOverflow, f)
+		}
+		return int64(f), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if n, err := strconv.ParseInt(s, intBase(s), 64); err == nil {
+			return n, nil
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%w: %q 转换为 int64", ErrOverflow, s)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无法将 %q 转换为 int64", s)
+		}
+		return ToInt64(f)
+	default:
+		return 0, unsupported(v, "int64")
+	}
+}
+
+// intBase 有0x、0o、0b前缀时返回0由strconv识别进制，否则按十进制，避免前导0被当作八进制
+func intBase(s string) int {
+	t := strings.TrimLeft(s, "+-")
+	if len(t) > 2 && t[0] == '0' && strings.ContainsRune("xXoObB", rune(t[1])) {
+		return 0
+	}
+	return 10
+}
+
+// ToUint64 转换为uint64，负数返回ErrOverflow
+func ToUint64(v any) (uint64, error) {
+	rv := indirect(v)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || f < 0 || f >= math.MaxUint64 {
+			return 0, fmt.Errorf("%w: %v 转换为 uint64", ErrOverflow, f)
+		}
+		return uint64(f), nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		if n, err := strconv.ParseUint(s, intBase(s), 64); err == nil {
+			return n, nil
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%w: %q 转换为 uint64", ErrOverflow, s)
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无法将 %q 转换为 uint64", s)
+		}
+		return ToUint64(f)
+	default:
+		n, err := ToInt64(v)
+		if err != nil {
+			return 0, err
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("%w: %d 转换为 uint64", ErrOverflow, n)
+		}
+		return uint64(n), nil
+	}
+}
+
+// ToFloat64 转换为float64
+func ToFloat64(v any) (float64, error) {
+	rv := indirect(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		f, err := strconv.ParseFloat(s, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%w: %q 转换为 float64", ErrOverflow, s)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("无法将 %q 转换为 float64", s)
+		}
+		return f, nil
+	default:
+		return 0, unsupported(v, "float64")
+	}
+}
+
+// ToBool 转换为bool，数值非0为true，字符串支持1/0、true/false、yes/no、on/off
+func ToBool(v any) (bool, error) {
+	rv := indirect(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		switch strings.ToLower(strings.TrimSpace(rv.String())) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "0", "f", "false", "n", "no", "off", "":
+			return false, nil
+		default:
+			return false, fmt.Errorf("无法将 %q 转换为 bool", rv.String())
+		}
+	default:
+		f, err := ToFloat64(v)
+		if err != nil {
+			return false, unsupported(v, "bool")
+		}
+		return f != 0, nil
+	}
+}
+
+// ToString 转换为string，[]byte直接转换，切片、map和结构体序列化为json
+func ToString(v any) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return x, nil
+	case []byte:
+		return string(x), nil
+	case time.Time:
+		return x.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return common.FormatDuration(x), nil
+	case error:
+		return x.Error(), nil
+	case fmt.Stringer:
+		return x.String(), nil
+	}
+
+	rv := indirect(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return "", nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		b, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return "", unsupported(v, "string")
+	}
+}
+
+// ToTime 转换为time.Time，字符串按TimeLayouts依次解析(无时区时使用本地时区)
+// 整数视为Unix秒，绝对值不小于1e12时视为Unix毫秒
+func ToTime(v any) (time.Time, error) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, nil
+	case *time.Time:
+		if x != nil {
+			return *x, nil
+		}
+	}
+
+	rv := indirect(v)
+	switch rv.Kind() {
+	case reflect.String:
+		s := strings.TrimSpace(rv.String())
+		for _, layout := range TimeLayouts {
+			if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return ToTime(n)
+		}
+		return time.Time{}, fmt.Errorf("无法将 %q 转换为 time.Time", s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		n, err := ToInt64(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if n >= 1e12 || n <= -1e12 {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	default:
+		return time.Time{}, unsupported(v, "time.Time")
+	}
+}
+
+// ToDuration 转换为time.Duration，整数视为纳秒，字符串支持"1d2h"等带天、周的格式
+func ToDuration(v any) (time.Duration, error) {
+	if d, ok := v.(time.Duration); ok {
+		return d, nil
+	}
+
+	rv := indirect(v)
+	if rv.Kind() == reflect.String {
+		s := strings.TrimSpace(rv.String())
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Duration(n), nil
+		}
+		return common.ParseDuration(s)
+	}
+	n, err := ToInt64(v)
+	if err != nil {
+		return 0, unsupported(v, "time.Duration")
+	}
+	return time.Duration(n), nil
+}
+
+// To 转换为T，支持各宽度的整数和浮点数、bool、string、time.Time、time.Duration及以它们为底层类型的自定义类型
+// 窄类型超出范围时返回ErrOverflow
+func To[T any](v any) (T, error) {
+	var zero T
+	if t, ok := v.(T); ok {
+		return t, nil
+	}
+
+	target := reflect.TypeOf(&zero).Elem()
+	ret := reflect.New(target).Elem()
+	switch any(zero).(type) {
+	case time.Time:
+		t, err := ToTime(v)
+		return any(t).(T), err
+	case time.Duration:
+		d, err := ToDuration(v)
+		return any(d).(T), err
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := ToInt64(v)
+		if err != nil {
+			return zero, err
+		}
+		if ret.OverflowInt(n) {
+			return zero, fmt.Errorf("%w: %d 转换为 %s", ErrOverflow, n, target)
+		}
+		ret.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := ToUint64(v)
+		if err != nil {
+			return zero, err
+		}
+		if ret.OverflowUint(n) {
+			return zero, fmt.Errorf("%w: %d 转换为 %s", ErrOverflow, n, target)
+		}
+		ret.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := ToFloat64(v)
+		if err != nil {
+			return zero, err
+		}
+		if ret.OverflowFloat(f) {
+			return zero, fmt.Errorf("%w: %v 转换为 %s", ErrOverflow, f, target)
+		}
+		ret.SetFloat(f)
+	case reflect.Bool:
+		b, err := ToBool(v)
+		if err != nil {
+			return zero, err
+		}
+		ret.SetBool(b)
+	case reflect.String:
+		s, err := ToString(v)
+		if err != nil {
+			return zero, err
+		}
+		ret.SetString(s)
+	default:
+		return zero, unsupported(v, target.String())
+	}
+	return ret.Interface().(T), nil
+}
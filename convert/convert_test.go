@@ -0,0 +1,136 @@
+package convert
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestToInt64(t *testing.T) {
+	n := 42
+	var nilPtr *int
+	tests := []struct {
+		in       any
+		want     int64
+		overflow bool
+		fail     bool
+	}{
+		{"010", 10, false, false},
+		{"08", 8, false, false},
+		{"-007", -7, false, false},
+		{" 12 ", 12, false, false},
+		{"0x1F", 31, false, false},
+		{"-0x10", -16, false, false},
+		{"0b101", 5, false, false},
+		{"0o17", 15, false, false},
+		{"3.9", 3, false, false},
+		{"1e3", 1000, false, false},
+		{"9223372036854775807", math.MaxInt64, false, false},
+		{"9223372036854775808", 0, true, false},
+		{"1e19", 0, true, false},
+		{"abc", 0, false, true},
+		{"NaN", 0, true, false},
+		{math.NaN(), 0, true, false},
+		{math.Inf(1), 0, true, false},
+		{float64(math.MaxInt64), 0, true, false},
+		{uint64(math.MaxUint64), 0, true, false},
+		{-2.7, -2, false, false},
+		{true, 1, false, false},
+		{false, 0, false, false},
+		{&n, 42, false, false},
+		{nilPtr, 0, false, true},
+		{nil, 0, false, true},
+		{[]int{1}, 0, false, true},
+	}
+	for _, tt := range tests {
+		got, err := ToInt64(tt.in)
+		switch {
+		case tt.overflow:
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("ToInt64(%#v) err = %v, want ErrOverflow", tt.in, err)
+			}
+		case tt.fail:
+			if err == nil {
+				t.Errorf("ToInt64(%#v) = %d, want error", tt.in, got)
+			}
+		case err != nil || got != tt.want:
+			t.Errorf("ToInt64(%#v) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestToUint64(t *testing.T) {
+	tests := []struct {
+		in       any
+		want     uint64
+		overflow bool
+	}{
+		{"010", 10, false},
+		{"0x10", 16, false},
+		{"18446744073709551615", math.MaxUint64, false},
+		{"18446744073709551616", 0, true},
+		{"-1", 0, true},
+		{-1, 0, true},
+		{-0.5, 0, true},
+		{math.NaN(), 0, true},
+		{true, 1, false},
+		{int8(7), 7, false},
+	}
+	for _, tt := range tests {
+		got, err := ToUint64(tt.in)
+		if tt.overflow {
+			if !errors.Is(err, ErrOverflow) {
+				t.Errorf("ToUint64(%#v) err = %v, want ErrOverflow", tt.in, err)
+			}
+		} else if err != nil || got != tt.want {
+			t.Errorf("ToUint64(%#v) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestTo(t *testing.T) {
+	type level int8
+
+	if v, err := To[int]("0123"); err != nil || v != 123 {
+		t.Errorf("To[int](\"0123\") = %d, %v", v, err)
+	}
+	if v, err := To[int8]("127"); err != nil || v != 127 {
+		t.Errorf("To[int8](\"127\") = %d, %v", v, err)
+	}
+	for _, in := range []any{"128", 128, -129, 1e10} {
+		if _, err := To[int8](in); !errors.Is(err, ErrOverflow) {
+			t.Errorf("To[int8](%#v) err = %v, want ErrOverflow", in, err)
+		}
+	}
+	if _, err := To[uint8](256); !errors.Is(err, ErrOverflow) {
+		t.Errorf("To[uint8](256) err = %v, want ErrOverflow", err)
+	}
+	if _, err := To[float32](1e40); !errors.Is(err, ErrOverflow) {
+		t.Errorf("To[float32](1e40) err = %v, want ErrOverflow", err)
+	}
+	if v, err := To[level]("3"); err != nil || v != 3 {
+		t.Errorf("To[level] = %d, %v", v, err)
+	}
+	if v, err := To[bool]("yes"); err != nil || !v {
+		t.Errorf("To[bool](\"yes\") = %v, %v", v, err)
+	}
+	if _, err := To[bool]("maybe"); err == nil {
+		t.Error("To[bool](\"maybe\") 应返回错误")
+	}
+	if v, err := To[string](3.5); err != nil || v != "3.5" {
+		t.Errorf("To[string](3.5) = %q, %v", v, err)
+	}
+	if v, err := To[string](map[string]int{"a": 1}); err != nil || v != `{"a":1}` {
+		t.Errorf("To[string](map) = %q, %v", v, err)
+	}
+	if v, err := To[time.Duration]("1d2h"); err != nil || v != 26*time.Hour {
+		t.Errorf("To[time.Duration](\"1d2h\") = %v, %v", v, err)
+	}
+	if v, err := To[time.Time](int64(1700000000000)); err != nil || !v.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("To[time.Time](ms) = %v, %v", v, err)
+	}
+	if _, err := To[[]int]("1"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("To[[]int] err = %v, want ErrUnsupported", err)
+	}
+}
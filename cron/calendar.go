@@ -0,0 +1,278 @@
+package cron
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Calendar 工作日历，判断某天是否为工作日
+type Calendar interface {
+	IsWorkday(t time.Time) bool
+}
+
+// Weekdays 周一至周五为工作日，不考虑节假日
+var Weekdays Calendar = weekdayCalendar{}
+
+type weekdayCalendar struct{}
+
+func (weekdayCalendar) IsWorkday(t time.Time) bool {
+	wd := t.Weekday()
+	return wd != time.Saturday && wd != time.Sunday
+}
+
+type date struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) date {
+	y, m, d := t.Date()
+	return date{y, m, d}
+}
+
+// HolidayCalendar 节假日日历，在周一至周五工作的基础上加入法定节假日和调休上班日(如国庆调休的周六)
+type HolidayCalendar struct {
+	mu       sync.RWMutex
+	holidays map[date]string
+	workdays map[date]string
+}
+
+// NewHolidayCalendar 创建空的节假日日历，未添加任何日期时等同于Weekdays
+func NewHolidayCalendar() *HolidayCalendar {
+	return &HolidayCalendar{
+		holidays: make(map[date]string),
+		workdays: make(map[date]string),
+	}
+}
+
+// AddHoliday 添加休息日，包括from和to，name为节日名称
+func (c *HolidayCalendar) AddHoliday(from, to time.Time, name string) {
+	c.add(c.holidays, c.workdays, from, to, name)
+}
+
+// AddWorkday 添加调休上班日，包括from和to
+func (c *HolidayCalendar) AddWorkday(from, to time.Time, name string) {
+	c.add(c.workdays, c.holidays, from, to, name)
+}
+
+func (c *HolidayCalendar) add(set, other map[date]string, from, to time.Time, name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		set[dateOf(d)] = name
+		delete(other, dateOf(d))
+	}
+}
+
+// IsWorkday 调休上班日为工作日，节假日和周末为休息日
+func (c *HolidayCalendar) IsWorkday(t time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	d := dateOf(t)
+	if _, ok := c.workdays[d]; ok {
+		return true
+	}
+	if _, ok := c.holidays[d]; ok {
+		return false
+	}
+	return Weekdays.IsWorkday(t)
+}
+
+// Holiday 返回节假日名称，不是节假日时返回false
+func (c *HolidayCalendar) Holiday(t time.Time) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	name, ok := c.holidays[dateOf(t)]
+	return name, ok
+}
+
+// LoadCalendar 从文件读取节假日表，格式见ParseCalendar
+func LoadCalendar(path string) (*HolidayCalendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCalendar(f)
+}
+
+// ParseCalendar 读取节假日表，每行为 日期或日期范围 类型 [名称]，#开头为注释：
+//
+//	# 2026年国庆节
+//	2026-10-01~2026-10-07 休 国庆节
+//	2026-10-10 班 国庆节调休
+//
+// 类型为 休、holiday、off 表示休息，班、workday、work 表示上班
+func ParseCalendar(r io.Reader) (*HolidayCalendar, error) {
+	c := NewHolidayCalendar()
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("第%d行格式错误: %s", n, line)
+		}
+
+		fromStr, toStr, isRange := strings.Cut(fields[0], "~")
+		from, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			return nil, fmt.Errorf("第%d行日期错误: %s", n, fromStr)
+		}
+		to := from
+		if isRange {
+			if to, err = time.Parse(time.DateOnly, toStr); err != nil || to.Before(from) {
+				return nil, fmt.Errorf("第%d行日期错误: %s", n, fields[0])
+			}
+		}
+		name := strings.Join(fields[2:], " ")
+
+		switch strings.ToLower(fields[1]) {
+		case "休", "holiday", "off":
+			c.AddHoliday(from, to, name)
+		case "班", "workday", "work":
+			c.AddWorkday(from, to, name)
+		default:
+			return nil, fmt.Errorf("第%d行类型错误: %s", n, fields[1])
+		}
+	}
+	return c, scanner.Err()
+}
+
+// WorkdaysOnly 只在工作日执行，非工作日的执行时间被跳过，cal为nil时使用Weekdays
+// 按s的时区判断日期(cron表达式的CRON_TZ)，s不提供时区时按本地时区
+func WorkdaysOnly(s Schedule, cal Calendar) Schedule {
+	if cal == nil {
+		cal = Weekdays
+	}
+	return filtered{Schedule: s, cal: cal, workday: true}
+}
+
+// NonWorkdaysOnly 只在休息日(周末和节假日)执行，cal为nil时使用Weekdays
+func NonWorkdaysOnly(s Schedule, cal Calendar) Schedule {
+	if cal == nil {
+		cal = Weekdays
+	}
+	return filtered{Schedule: s, cal: cal}
+}
+
+type filtered struct {
+	Schedule
+	cal     Calendar
+	workday bool
+}
+
+// 最多向后查找的年数，避免日历全是休息日时死循环
+const maxFilterYears = 5
+
+func (f filtered) Next(t time.Time) time.Time {
+	loc := time.Local
+	if l, ok := f.Schedule.(interface{ Location() *time.Location }); ok {
+		loc = l.Location()
+	}
+	limit := t.AddDate(maxFilterYears, 0, 0)
+	for {
+		t = f.Schedule.Next(t)
+		if t.IsZero() || t.After(limit) {
+			return time.Time{}
+		}
+		lt := t.In(loc)
+		if f.cal.IsWorkday(lt) == f.workday {
+			return t
+		}
+
+		// 当天不满足条件，直接从次日0点继续查找，而不是逐个跳过当天的执行时间
+		y, m, d := lt.Date()
+		next := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+		if e, ok := f.Schedule.(every); ok {
+			// 固定间隔保持原有相位
+			n := (next.Sub(t) - 1) / time.Duration(e)
+			t = t.Add(n * time.Duration(e))
+		} else {
+			t = next.Add(-time.Nanosecond)
+		}
+	}
+}
+
+func (f filtered) String() string {
+	if f.workday {
+		return fmt.Sprintf("%v (workdays)", f.Schedule)
+	}
+	return fmt.Sprintf("%v (non-workdays)", f.Schedule)
+}
+
+// MonthlyWorkday 每月第N个工作日的指定时刻执行
+type MonthlyWorkday struct {
+	N        int            // 1为第一个工作日，-1为最后一个工作日，不能为0
+	Hour     int            // 执行时刻
+	Minute   int            //
+	Calendar Calendar       // 为nil时使用Weekdays
+	Location *time.Location // 为nil时使用本地时区
+}
+
+// LastWorkdayOfMonth 每月最后一个工作日的hour:minute执行
+func LastWorkdayOfMonth(hour, minute int, cal Calendar) Schedule {
+	return MonthlyWorkday{N: -1, Hour: hour, Minute: minute, Calendar: cal}
+}
+
+// FirstWorkdayOfMonth 每月第一个工作日的hour:minute执行
+func FirstWorkdayOfMonth(hour, minute int, cal Calendar) Schedule {
+	return MonthlyWorkday{N: 1, Hour: hour, Minute: minute, Calendar: cal}
+}
+
+func (m MonthlyWorkday) Next(t time.Time) time.Time {
+	if m.N == 0 {
+		return time.Time{}
+	}
+	loc, cal := m.Location, m.Calendar
+	if loc == nil {
+		loc = time.Local
+	}
+	if cal == nil {
+		cal = Weekdays
+	}
+
+	lt := t.In(loc)
+	for i := 0; i < 60; i++ {
+		month := time.Date(lt.Year(), lt.Month()+time.Month(i), 1, 0, 0, 0, 0, loc)
+		if day := m.day(month, cal); day > 0 {
+			run := time.Date(month.Year(), month.Month(), day, m.Hour, m.Minute, 0, 0, loc)
+			if run.After(t) {
+				return run.In(t.Location())
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// day 返回month当月第N个工作日，不存在时返回0
+func (m MonthlyWorkday) day(month time.Time, cal Calendar) int {
+	days := daysIn(month)
+	count := 0
+	for i := 1; i <= days; i++ {
+		d := i
+		if m.N < 0 {
+			d = days + 1 - i
+		}
+		if cal.IsWorkday(time.Date(month.Year(), month.Month(), d, 12, 0, 0, 0, month.Location())) {
+			if count++; count == m.N || -count == m.N {
+				return d
+			}
+		}
+	}
+	return 0
+}
+
+func (m MonthlyWorkday) String() string {
+	return fmt.Sprintf("workday %d of month at %02d:%02d", m.N, m.Hour, m.Minute)
+}
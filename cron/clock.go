@@ -0,0 +1,56 @@
+package cron
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mzky/utils/common"
+)
+
+// JumpPolicy 检测到修改系统时间后，按cron表达式执行的任务如何处理下次执行时间
+// 固定间隔的任务使用单调时间，不受修改系统时间影响
+type JumpPolicy int
+
+const (
+	// JumpKeep 保持原定的下次执行时间：调快后已到期的任务只执行一次，调慢后等待到原定时间再执行
+	JumpKeep JumpPolicy = iota
+	// JumpRecompute 按调整后的时间重新计算下次执行时间：调快时跳过错过的执行，调慢时可能再次执行已执行过的时间点
+	JumpRecompute
+)
+
+// JumpThreshold 墙上时间与单调时间的偏差超过该值时视为修改了系统时间
+var JumpThreshold = 2 * time.Second
+
+// jumpDetector 所有任务共用的跳变检测，每次检查都以上次检查为基准，同一次跳变只报告一次
+type jumpDetector struct {
+	mu    sync.Mutex
+	wall  time.Time
+	mono  time.Duration
+	epoch uint64 // 检测到跳变的次数
+}
+
+func (d *jumpDetector) reset(c common.Clock) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.wall, d.mono = c.Now(), c.Monotonic()
+}
+
+func (d *jumpDetector) current() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.epoch
+}
+
+// check 返回跳变次数，本次检查发现新的跳变时delta不为0
+func (d *jumpDetector) check(c common.Clock) (epoch uint64, delta time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	wall, mono := c.Now(), c.Monotonic()
+	delta = common.ClockJump(d.wall, d.mono, wall, mono)
+	d.wall, d.mono = wall, mono
+	if delta > JumpThreshold || delta < -JumpThreshold {
+		d.epoch++
+		return d.epoch, delta
+	}
+	return d.epoch, 0
+}
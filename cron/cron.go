@@ -1,101 +1,316 @@
 package cron
 
 import (
-	"reflect"
+	"context"
+	"errors"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/mzky/utils/common"
 )
 
-// Task 定义一个定时任务
-type Task struct {
-	interval time.Duration
-	fn       reflect.Value
-	args     []reflect.Value
-}
+var (
+	ErrTaskNotFound = errors.New("任务不存在")
+	ErrInterval     = errors.New("执行间隔必须大于0")
+)
 
-// TickerManager 管理定时器的启动和停止
+// TickerManager 管理定时器的启动和停止，所有方法均可在运行期间并发调用
 type TickerManager struct {
-	tasks     []Task
+	tasks     map[TaskID]*Task
+	lastID    TaskID
 	IsRunning bool
 	wg        sync.WaitGroup
 	mu        sync.Mutex
-	stop      bool
+	cancel    context.CancelFunc // Stop时调用，取消所有任务
+	ctx       context.Context
+
+	clock      common.Clock
+	jumpPolicy JumpPolicy
+	onJump     func(delta time.Duration)
+	jump       jumpDetector
+	onError    func(id TaskID, err error)
+
+	state       stateFile
+	historySize int
+	onStart     func(id TaskID)
+	onSuccess   func(id TaskID, duration time.Duration)
+
+	host string        // 计算Spread偏移的主机名
+	sem  chan struct{} // 同时执行的任务数上限，为nil时不限制
 }
 
 // NewTickerManager 创建一个新的 TickerManager 实例
 func NewTickerManager() *TickerManager {
 	return &TickerManager{
-		IsRunning: false,
-		stop:      false,
+		tasks:       make(map[TaskID]*Task),
+		IsRunning:   false,
+		clock:       common.SystemClock,
+		historySize: DefaultHistorySize,
+		host:        hostname(),
 	}
 }
 
-// RegisterTask 注册一个定时任务，支持任意数量和类型的参数
-func (tm *TickerManager) RegisterTask(interval time.Duration, fn interface{}, args ...interface{}) {
+// SetClock 设置时钟，测试时可使用common.FakeClock，需在Start之前调用
+func (tm *TickerManager) SetClock(c common.Clock) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.clock = c
+}
+
+// SetJumpPolicy 设置修改系统时间后的处理方式，默认JumpKeep，需在Start之前调用
+func (tm *TickerManager) SetJumpPolicy(p JumpPolicy) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.jumpPolicy = p
+}
+
+// OnClockJump 设置检测到修改系统时间时的回调，delta为正表示调快，需在Start之前调用
+// 只有存在按cron表达式执行的任务时才会检测
+func (tm *TickerManager) OnClockJump(fn func(delta time.Duration)) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	tm.tasks = append(tm.tasks, Task{
-		interval: interval,
-		fn:       reflect.ValueOf(fn),
-		args:     toReflectValues(args),
-	})
+	tm.onJump = fn
 }
 
-// toReflectValues 将接口切片转换为反射值切片
-func toReflectValues(args []interface{}) []reflect.Value {
-	var result []reflect.Value
-	for _, arg := range args {
-		result = append(result, reflect.ValueOf(arg))
+// RegisterTask 注册一个定时任务，支持任意数量和类型的参数，返回任务编号
+// 注册时检查函数与参数是否匹配，fn的第一个参数为context.Context且args中不包含该参数时自动传入ctx
+// 管理器运行期间注册的任务立即开始计时
+func (tm *TickerManager) RegisterTask(interval time.Duration, fn interface{}, args ...interface{}) (TaskID, error) {
+	if interval <= 0 {
+		return 0, ErrInterval
+	}
+	f, err := wrap(fn, args)
+	if err != nil {
+		return 0, err
+	}
+	return tm.add(&Task{interval: interval, fn: f}), nil
+}
+
+// RegisterCron 按cron表达式注册定时任务，表达式格式见ParseCron，可使用 CRON_TZ= 前缀为任务单独指定时区
+func (tm *TickerManager) RegisterCron(expr string, fn interface{}, args ...interface{}) (TaskID, error) {
+	s, err := ParseCron(expr)
+	if err != nil {
+		return 0, err
+	}
+	return tm.RegisterSchedule(s, fn, args...)
+}
+
+// RegisterSchedule 按自定义的Schedule注册定时任务
+func (tm *TickerManager) RegisterSchedule(s Schedule, fn interface{}, args ...interface{}) (TaskID, error) {
+	if s == nil {
+		return 0, errors.New("schedule不能为nil")
+	}
+	f, err := wrap(fn, args)
+	if err != nil {
+		return 0, err
 	}
-	return result
+	return tm.add(&Task{schedule: s, fn: f}), nil
+}
+
+// Add 按固定间隔注册类型安全的任务函数
+func (tm *TickerManager) Add(interval time.Duration, fn TaskFunc) (TaskID, error) {
+	return tm.RegisterTask(interval, fn)
+}
+
+// AddCron 按cron表达式注册类型安全的任务函数
+func (tm *TickerManager) AddCron(expr string, fn TaskFunc) (TaskID, error) {
+	return tm.RegisterCron(expr, fn)
+}
+
+// AddSchedule 按自定义的Schedule注册类型安全的任务函数
+func (tm *TickerManager) AddSchedule(s Schedule, fn TaskFunc) (TaskID, error) {
+	return tm.RegisterSchedule(s, fn)
+}
+
+func (tm *TickerManager) add(t *Task) TaskID {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.lastID++
+	t.id = tm.lastID
+	t.trigger = make(chan struct{}, 1)
+	tm.tasks[t.id] = t
+	if tm.IsRunning {
+		tm.launch(t)
+	}
+	return t.id
+}
+
+// Remove 删除任务，正在执行的任务的ctx被取消，排队和等待重试的执行不再进行
+func (tm *TickerManager) Remove(id TaskID) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	t, ok := tm.tasks[id]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	t.stop()
+	delete(tm.tasks, id)
+	return nil
+}
+
+// Pause 暂停任务，暂停期间到期的执行被跳过，RunNow仍可手动执行
+func (tm *TickerManager) Pause(id TaskID) error {
+	t, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	t.setPaused(true)
+	return nil
+}
+
+// Resume 恢复暂停的任务，从下一个执行时间开始执行，暂停期间错过的执行不补
+func (tm *TickerManager) Resume(id TaskID) error {
+	t, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	t.setPaused(false)
+	return nil
+}
+
+// RunNow 立即执行一次任务，不影响原有的执行计划
+// 管理器运行时异步执行并遵循任务的重叠策略；未运行时在当前协程中同步执行
+func (tm *TickerManager) RunNow(id TaskID) error {
+	tm.mu.Lock()
+	t, ok := tm.tasks[id]
+	running := tm.IsRunning
+	tm.mu.Unlock()
+	if !ok {
+		return ErrTaskNotFound
+	}
+	if !running {
+		tm.execute(t, context.Background())
+		return nil
+	}
+	select {
+	case t.trigger <- struct{}{}:
+	default: // 已有一次待执行
+	}
+	return nil
+}
+
+// Next 查询任务的下次执行时间，管理器未启动或任务不再执行时返回零值
+func (tm *TickerManager) Next(id TaskID) (time.Time, error) {
+	info, err := tm.Task(id)
+	return info.Next, err
+}
+
+// Task 查询单个任务的信息
+func (tm *TickerManager) Task(id TaskID) (TaskInfo, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	t, ok := tm.tasks[id]
+	if !ok {
+		return TaskInfo{}, ErrTaskNotFound
+	}
+	return t.info(tm.clock, tm.IsRunning), nil
+}
+
+// Tasks 按编号顺序列出所有任务
+func (tm *TickerManager) Tasks() []TaskInfo {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	infos := make([]TaskInfo, 0, len(tm.tasks))
+	for _, t := range tm.tasks {
+		infos = append(infos, t.info(tm.clock, tm.IsRunning))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+func (tm *TickerManager) get(id TaskID) (*Task, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	t, ok := tm.tasks[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	return t, nil
 }
 
 // Start 启动所有定时任务
 func (tm *TickerManager) Start() {
 	tm.mu.Lock()
+	defer tm.mu.Unlock()
 	if tm.IsRunning {
-		tm.mu.Unlock()
 		return
 	}
 	tm.IsRunning = true
-	tm.stop = false
-	tm.mu.Unlock()
+	tm.ctx, tm.cancel = context.WithCancel(context.Background())
+	tm.jump.reset(tm.clock)
 
-	for _, task := range tm.tasks {
-		tm.wg.Add(1)
-		go tm.startTask(task)
+	for _, t := range tm.tasks {
+		tm.launch(t)
 	}
 }
 
-// startTask 启动单个定时任务
-func (tm *TickerManager) startTask(task Task) {
+// launch 启动任务协程，有状态记录时按MisfirePolicy补执行，需持有tm.mu
+func (tm *TickerManager) launch(t *Task) {
+	t.reset(tm.clock, tm.jump.current())
+	ctx, cancel := context.WithCancel(tm.ctx)
+	t.mu.Lock()
+	t.cancel = cancel
+	misfires := 0
+	if last, ok := tm.state.lastRun(t.opts.Name); ok {
+		misfires = t.misfires(tm.clock, last)
+	}
+	t.mu.Unlock()
+
+	tm.wg.Add(1)
+	go tm.runTask(t, ctx)
+	tm.catchUp(t, ctx, misfires)
+}
+
+// runTask 执行单个任务直到停止或删除
+// 不使用一次性的长定时器，而是每次最多等待1秒后重新检查：
+// 固定间隔任务按单调时间计时，不受修改系统时间影响；cron任务按墙上时间判断，修改系统时间后按JumpPolicy处理
+func (tm *TickerManager) runTask(t *Task, ctx context.Context) {
 	defer tm.wg.Done()
-	ticker := time.NewTicker(task.interval)
-	defer ticker.Stop()
+	tm.mu.Lock()
+	c, host := tm.clock, tm.host
+	tm.mu.Unlock()
 
 	for {
+		wait, ok := t.wait(c)
+		if !ok {
+			// 不再有执行时间，只响应手动执行
+			wait = time.Hour
+		}
+		if wait > time.Second {
+			wait = time.Second
+		}
 		select {
-		case <-ticker.C:
-			if tm.stop {
-				return
-			}
-			task.fn.Call(task.args)
-		case <-time.After(time.Second): // 避免阻塞
-			if tm.stop {
-				return
-			}
+		case <-ctx.Done():
+			return
+		case <-t.trigger:
+			tm.dispatch(t, ctx)
+			continue
+		case <-c.After(wait):
 		}
+
+		run, jump := t.due(tm)
+		if jump != 0 && tm.onJump != nil {
+			tm.onJump(jump)
+		}
+		if !run {
+			continue
+		}
+		if d := t.delay(host); d > 0 && !tm.sleep(t, ctx, d) {
+			return
+		}
+		tm.dispatch(t, ctx)
 	}
 }
 
-// Stop 停止所有定时任务
+// Stop 停止所有定时任务，取消正在执行的任务的ctx并等待其返回，排队和等待重试的执行不再进行
 func (tm *TickerManager) Stop() {
 	tm.mu.Lock()
 	if !tm.IsRunning {
 		tm.mu.Unlock()
 		return
 	}
-	tm.stop = true
+	tm.cancel()
 	tm.IsRunning = false
 	tm.mu.Unlock()
 
@@ -106,5 +321,8 @@ func (tm *TickerManager) Stop() {
 func (tm *TickerManager) ClearTasks() {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
-	tm.tasks = []Task{}
+	for id, t := range tm.tasks {
+		t.stop()
+		delete(tm.tasks, id)
+	}
 }
@@ -0,0 +1,875 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mzky/utils/common"
+)
+
+var start = time.Date(2026, 10, 19, 10, 0, 30, 0, time.Local)
+
+type counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *counter) inc() {
+	c.mu.Lock()
+	c.n++
+	c.mu.Unlock()
+}
+
+func (c *counter) get() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// tick 等待waiters个任务进入等待后前进1秒，重复n次，返回时所有任务已处理完本次时间
+func tick(tm *TickerManager, c *common.FakeClock, waiters, n int) {
+	for i := 0; i < n; i++ {
+		c.BlockUntil(waiters)
+		settle(tm)
+		c.Advance(time.Second)
+	}
+	c.BlockUntil(waiters)
+	settle(tm)
+}
+
+// settle 等待所有任务执行结束
+func settle(tm *TickerManager) {
+	for busy := true; busy; {
+		busy = false
+		tm.mu.Lock()
+		for _, t := range tm.tasks {
+			t.mu.Lock()
+			busy = busy || t.running > 0
+			t.mu.Unlock()
+		}
+		tm.mu.Unlock()
+		if busy {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+// must 注册失败时终止测试
+func must(t *testing.T) func(TaskID, error) TaskID {
+	return func(id TaskID, err error) TaskID {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+}
+
+func newManager(c *common.FakeClock, p JumpPolicy) *TickerManager {
+	tm := NewTickerManager()
+	tm.SetClock(c)
+	tm.SetJumpPolicy(p)
+	return tm
+}
+
+func TestIntervalIgnoresClockJump(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	var n counter
+	tm.RegisterTask(10*time.Second, n.inc)
+	tm.Start()
+	defer tm.Stop()
+
+	tick(tm, c, 1, 10)
+	if got := n.get(); got != 1 {
+		t.Fatalf("runs = %d, want 1", got)
+	}
+	c.Set(c.Now().Add(24 * time.Hour))
+	tick(tm, c, 1, 10)
+	if got := n.get(); got != 2 {
+		t.Fatalf("after forward jump runs = %d, want 2", got)
+	}
+	c.Set(c.Now().Add(-48 * time.Hour))
+	tick(tm, c, 1, 9)
+	if got := n.get(); got != 2 {
+		t.Fatalf("after backward jump runs = %d, want 2", got)
+	}
+	tick(tm, c, 1, 1)
+	if got := n.get(); got != 3 {
+		t.Fatalf("after backward jump runs = %d, want 3", got)
+	}
+}
+
+func TestCronForwardJump(t *testing.T) {
+	tests := []struct {
+		policy    JumpPolicy
+		afterJump int // 调快后立即执行的次数
+	}{
+		{JumpKeep, 1},
+		{JumpRecompute, 0},
+	}
+	for _, tt := range tests {
+		c := common.NewFakeClock(start)
+		tm := newManager(c, tt.policy)
+		var a, b counter
+		var jumps []time.Duration
+		tm.OnClockJump(func(delta time.Duration) { jumps = append(jumps, delta) })
+		if _, err := tm.RegisterCron("0 * * * * *", a.inc); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tm.RegisterCron("0 * * * * *", b.inc); err != nil {
+			t.Fatal(err)
+		}
+		tm.Start()
+
+		tick(tm, c, 2, 30)
+		if got := a.get(); got != 1 {
+			t.Fatalf("policy %d: runs at 10:01:00 = %d, want 1", tt.policy, got)
+		}
+
+		// 10:01:00 调到 10:05:30
+		c.Set(start.Add(5 * time.Minute))
+		tick(tm, c, 2, 1)
+		if got := a.get(); got != 1+tt.afterJump {
+			t.Fatalf("policy %d: runs after jump = %d, want %d", tt.policy, got, 1+tt.afterJump)
+		}
+		if len(jumps) != 1 || jumps[0] != 4*time.Minute+30*time.Second {
+			t.Fatalf("policy %d: jumps = %v, want [4m30s]", tt.policy, jumps)
+		}
+
+		tick(tm, c, 2, 29)
+		if got := a.get(); got != 2+tt.afterJump {
+			t.Fatalf("policy %d: runs at 10:06:00 = %d, want %d", tt.policy, got, 2+tt.afterJump)
+		}
+		if a.get() != b.get() {
+			t.Fatalf("policy %d: tasks ran %d and %d times", tt.policy, a.get(), b.get())
+		}
+		tm.Stop()
+	}
+}
+
+func TestCronBackwardJump(t *testing.T) {
+	tests := []struct {
+		policy JumpPolicy
+		wait   int // 调慢后再次执行前经过的秒数
+	}{
+		{JumpKeep, 150},     // 等到原定的10:02:00
+		{JumpRecompute, 30}, // 重新计算为10:00:00
+	}
+	for _, tt := range tests {
+		c := common.NewFakeClock(start)
+		tm := newManager(c, tt.policy)
+		var n counter
+		var jumps []time.Duration
+		tm.OnClockJump(func(delta time.Duration) { jumps = append(jumps, delta) })
+		if _, err := tm.RegisterCron("0 * * * * *", n.inc); err != nil {
+			t.Fatal(err)
+		}
+		tm.Start()
+
+		tick(tm, c, 1, 30)
+		if got := n.get(); got != 1 {
+			t.Fatalf("policy %d: runs at 10:01:00 = %d, want 1", tt.policy, got)
+		}
+
+		// 10:01:00 调到 09:59:30
+		c.Set(start.Add(-time.Minute))
+		tick(tm, c, 1, tt.wait-1)
+		if got := n.get(); got != 1 {
+			t.Fatalf("policy %d: runs before %ds = %d, want 1", tt.policy, tt.wait, got)
+		}
+		if len(jumps) != 1 || jumps[0] != -90*time.Second {
+			t.Fatalf("policy %d: jumps = %v, want [-1m30s]", tt.policy, jumps)
+		}
+		tick(tm, c, 1, 1)
+		if got := n.get(); got != 2 {
+			t.Fatalf("policy %d: runs after %ds = %d, want 2", tt.policy, tt.wait, got)
+		}
+		tm.Stop()
+	}
+}
+
+func TestStopWhileWaiting(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	tm.RegisterTask(time.Hour, func() {})
+	if _, err := tm.RegisterCron("@daily", func() {}); err != nil {
+		t.Fatal(err)
+	}
+	tm.Start()
+	c.BlockUntil(2)
+
+	done := make(chan struct{})
+	go func() {
+		tm.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return while tasks were waiting")
+	}
+}
+
+func TestRegisterAfterStart(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	tm.Start()
+	defer tm.Stop()
+
+	var n counter
+	id := must(t)(tm.RegisterTask(5*time.Second, n.inc))
+	next, err := tm.Next(id)
+	if err != nil || !next.Equal(start.Add(5*time.Second)) {
+		t.Fatalf("Next = %v, %v, want %v", next, err, start.Add(5*time.Second))
+	}
+	tick(tm, c, 1, 5)
+	if got := n.get(); got != 1 {
+		t.Fatalf("runs = %d, want 1", got)
+	}
+}
+
+func TestPauseResumeRemove(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	var a, b counter
+	ida := must(t)(tm.RegisterTask(time.Second, a.inc))
+	idb, err := tm.RegisterCron("* * * * * *", b.inc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm.Start()
+	defer tm.Stop()
+
+	tick(tm, c, 2, 3)
+	if a.get() != 3 || b.get() != 3 {
+		t.Fatalf("runs = %d, %d, want 3, 3", a.get(), b.get())
+	}
+
+	if err = tm.Pause(ida); err != nil {
+		t.Fatal(err)
+	}
+	tick(tm, c, 2, 3)
+	if a.get() != 3 || b.get() != 6 {
+		t.Fatalf("runs while paused = %d, %d, want 3, 6", a.get(), b.get())
+	}
+	if infos := tm.Tasks(); len(infos) != 2 || !infos[0].Paused || infos[1].Paused || infos[1].Schedule != "* * * * * *" {
+		t.Fatalf("Tasks = %+v", infos)
+	}
+
+	if err = tm.Resume(ida); err != nil {
+		t.Fatal(err)
+	}
+	if err = tm.Remove(idb); err != nil {
+		t.Fatal(err)
+	}
+	tick(tm, c, 1, 2)
+	if a.get() != 5 || b.get() != 6 {
+		t.Fatalf("runs after resume/remove = %d, %d, want 5, 6", a.get(), b.get())
+	}
+	if err = tm.Remove(idb); err != ErrTaskNotFound {
+		t.Fatalf("Remove twice err = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func TestRunNow(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	ran := make(chan struct{}, 1)
+	id, err := tm.RegisterCron("@daily", func() { ran <- struct{}{} })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未启动时同步执行
+	if err = tm.RunNow(id); err != nil {
+		t.Fatal(err)
+	}
+	<-ran
+
+	tm.Start()
+	defer tm.Stop()
+	if err = tm.RunNow(id); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("RunNow did not run the task")
+	}
+	if next, _ := tm.Next(id); !next.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)) {
+		t.Fatalf("Next after RunNow = %v", next)
+	}
+	if err = tm.RunNow(TaskID(100)); err != ErrTaskNotFound {
+		t.Fatalf("RunNow unknown err = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func recv(t *testing.T, ch <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(time.Second):
+		t.Fatal("no error reported")
+		return nil
+	}
+}
+
+func TestPanicRecoveryAndRetry(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	errs := make(chan error, 10)
+	tm.OnError(func(id TaskID, err error) { errs <- err })
+
+	var n counter
+	id := must(t)(tm.RegisterTask(10*time.Second, func() error {
+		n.inc()
+		switch n.get() {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("fail")
+		}
+		return nil
+	}))
+	if err := tm.SetTaskOptions(id, TaskOptions{Retries: 3, RetryDelay: 2 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	tm.Start()
+	defer tm.Stop()
+
+	c.BlockUntil(1)
+	c.Advance(10 * time.Second)
+	var pe *PanicError
+	if err := recv(t, errs); !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("err = %v, want PanicError(boom)", err)
+	}
+
+	// 第一次重试等待2秒，第二次等待4秒
+	c.BlockUntil(2)
+	c.Advance(2 * time.Second)
+	if err := recv(t, errs); err == nil || err.Error() != "第1次重试: fail" {
+		t.Fatalf("err = %v, want 第1次重试: fail", err)
+	}
+	c.BlockUntil(2)
+	c.Advance(3 * time.Second)
+	c.BlockUntil(2)
+	if got := n.get(); got != 2 {
+		t.Fatalf("runs before backoff elapsed = %d, want 2", got)
+	}
+	c.Advance(time.Second)
+	c.BlockUntil(1)
+	settle(tm)
+	if got := n.get(); got != 3 {
+		t.Fatalf("runs = %d, want 3", got)
+	}
+	select {
+	case err := <-errs:
+		t.Fatalf("unexpected error %v", err)
+	default:
+	}
+}
+
+func TestOverlapPolicy(t *testing.T) {
+	for _, policy := range []OverlapPolicy{OverlapSkip, OverlapQueue, OverlapConcurrent} {
+		c := common.NewFakeClock(start)
+		tm := newManager(c, JumpKeep)
+		errs := make(chan error, 10)
+		tm.OnError(func(id TaskID, err error) { errs <- err })
+
+		started := make(chan struct{}, 10)
+		release := make(chan struct{})
+		id := must(t)(tm.RegisterTask(time.Second, func() {
+			started <- struct{}{}
+			<-release
+		}))
+		if err := tm.SetTaskOptions(id, TaskOptions{Overlap: policy}); err != nil {
+			t.Fatal(err)
+		}
+		tm.Start()
+
+		c.BlockUntil(1)
+		c.Advance(time.Second)
+		<-started
+		c.BlockUntil(1)
+		c.Advance(time.Second)
+		c.BlockUntil(1)
+
+		switch policy {
+		case OverlapSkip:
+			if err := recv(t, errs); err != ErrSkipped {
+				t.Fatalf("err = %v, want ErrSkipped", err)
+			}
+			close(release)
+		case OverlapQueue:
+			if len(started) != 0 {
+				t.Fatal("queued run started before the previous one finished")
+			}
+			release <- struct{}{}
+			<-started
+			close(release)
+		case OverlapConcurrent:
+			<-started
+			close(release)
+		}
+		settle(tm)
+		tm.Stop()
+		if len(started) != 0 || len(errs) != 0 {
+			t.Fatalf("policy %d: extra runs %d, errors %d", policy, len(started), len(errs))
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tm := NewTickerManager()
+	errs := make(chan error, 10)
+	tm.OnError(func(id TaskID, err error) { errs <- err })
+
+	withCtx := must(t)(tm.RegisterTask(time.Hour, func(ctx context.Context, name string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, "ctx"))
+	withoutCtx := must(t)(tm.RegisterTask(time.Hour, func() {
+		time.Sleep(30 * time.Millisecond)
+	}))
+	for _, id := range []TaskID{withCtx, withoutCtx} {
+		if err := tm.SetTaskOptions(id, TaskOptions{Timeout: 10 * time.Millisecond}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tm.RunNow(id); err != nil {
+			t.Fatal(err)
+		}
+		if err := recv(t, errs); !errors.Is(err, ErrTimeout) {
+			t.Fatalf("task %d err = %v, want ErrTimeout", id, err)
+		}
+	}
+}
+
+func TestRegisterValidation(t *testing.T) {
+	tm := NewTickerManager()
+	var nilFunc func()
+	tests := []struct {
+		fn   interface{}
+		args []interface{}
+		ok   bool
+	}{
+		{func() {}, nil, true},
+		{func(s string, n int) {}, []interface{}{"a", 1}, true},
+		{func(ctx context.Context, s string) error { return nil }, []interface{}{"a"}, true},
+		{func(ctx context.Context) {}, []interface{}{context.TODO()}, true},
+		{func(s string, n ...int) {}, []interface{}{"a"}, true},
+		{func(s string, n ...int) {}, []interface{}{"a", 1, 2}, true},
+		{func(p *int, m map[string]int) {}, []interface{}{nil, nil}, true},
+		{func(err error) {}, []interface{}{errors.New("x")}, true},
+		{"not a func", nil, false},
+		{nilFunc, nil, false},
+		{func(s string) {}, nil, false},
+		{func(s string) {}, []interface{}{1}, false},
+		{func(n int) {}, []interface{}{nil}, false},
+		{func(s string, n ...int) {}, []interface{}{"a", "b"}, false},
+		{func() {}, []interface{}{1}, false},
+	}
+	for i, tt := range tests {
+		_, err := tm.RegisterTask(time.Second, tt.fn, tt.args...)
+		if (err == nil) != tt.ok {
+			t.Errorf("case %d: err = %v, want ok = %v", i, err, tt.ok)
+		}
+	}
+	if _, err := tm.RegisterTask(0, func() {}); err != ErrInterval {
+		t.Errorf("interval 0 err = %v, want ErrInterval", err)
+	}
+}
+
+func TestStopCancelsRunningTask(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	errs := make(chan error, 10)
+	tm.OnError(func(id TaskID, err error) { errs <- err })
+
+	started := make(chan struct{})
+	canceled := make(chan error, 1)
+	must(t)(tm.Add(time.Second, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		canceled <- ctx.Err()
+		return ctx.Err()
+	}))
+	tm.Start()
+	c.BlockUntil(1)
+	c.Advance(time.Second)
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		tm.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not cancel the running task")
+	}
+	if err := <-canceled; err != context.Canceled {
+		t.Fatalf("ctx err = %v, want context.Canceled", err)
+	}
+	if len(errs) != 0 {
+		t.Fatalf("cancellation reported as error: %v", <-errs)
+	}
+}
+
+func TestHistoryAndHooks(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	tm.SetHistorySize(3)
+	var starts, successes, failures counter
+	tm.OnStart(func(id TaskID) { starts.inc() })
+	tm.OnSuccess(func(id TaskID, d time.Duration) { successes.inc() })
+	tm.OnError(func(id TaskID, err error) { failures.inc() })
+
+	var n counter
+	id := must(t)(tm.Add(time.Second, func(ctx context.Context) error {
+		n.inc()
+		if n.get()%2 == 0 {
+			return errors.New("even")
+		}
+		return nil
+	}))
+	tm.Start()
+	defer tm.Stop()
+	tick(tm, c, 1, 5)
+
+	s, err := tm.Status(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Total != 5 || s.Failed != 2 || len(s.History) != 3 {
+		t.Fatalf("status = %+v", s)
+	}
+	if starts.get() != 5 || successes.get() != 3 || failures.get() != 2 {
+		t.Fatalf("hooks = %d, %d, %d", starts.get(), successes.get(), failures.get())
+	}
+	// 保留最近3次：第3、4、5次
+	for i, want := range []string{"", "even", ""} {
+		if got := s.History[i].Error; got != want {
+			t.Fatalf("history[%d].Error = %q, want %q", i, got, want)
+		}
+		if wantStart := start.Add(time.Duration(i+3) * time.Second); !s.History[i].Start.Equal(wantStart) {
+			t.Fatalf("history[%d].Start = %v, want %v", i, s.History[i].Start, wantStart)
+		}
+	}
+	if s.Last == nil || !s.Last.Start.Equal(start.Add(5*time.Second)) {
+		t.Fatalf("last = %+v", s.Last)
+	}
+	if snap := tm.Snapshot(); len(snap) != 1 || snap[0].ID != id {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tm := NewTickerManager()
+	id := must(t)(tm.AddCron("@daily", func(ctx context.Context) error { return errors.New("failed") }))
+	tm.OnError(func(TaskID, error) {})
+	if err := tm.RunNow(id); err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/cron", tm.Handler())
+
+	tests := []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusOK},
+		{"?id=1&history=false", http.StatusOK},
+		{"?id=2", http.StatusNotFound},
+		{"?id=x", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cron"+tt.query, nil))
+		if w.Code != tt.code {
+			t.Fatalf("%s: code = %d, want %d", tt.query, w.Code, tt.code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cron", nil))
+	var list []TaskStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Schedule != "@daily" || list[0].Last == nil || list[0].Last.Error != "failed" {
+		t.Fatalf("list = %s", w.Body)
+	}
+}
+
+func TestMisfire(t *testing.T) {
+	tests := []struct {
+		policy MisfirePolicy
+		cron   int // 每天10:00的任务，停止3天
+		every  int // 每10秒的任务，停止25秒
+	}{
+		{MisfireRunOnce, 1, 1},
+		{MisfireRunAll, 3, 2},
+		{MisfireSkip, 0, 0},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "cron.json")
+		data, _ := json.Marshal(map[string]time.Time{
+			"daily": start.Add(-72*time.Hour - 30*time.Second),
+			"every": start.Add(-25 * time.Second),
+		})
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		c := common.NewFakeClock(start)
+		tm := newManager(c, JumpKeep)
+		if err := tm.SetStateFile(path); err != nil {
+			t.Fatal(err)
+		}
+		var daily, every, unnamed counter
+		idDaily := must(t)(tm.RegisterCron("0 0 10 * * *", daily.inc))
+		idEvery := must(t)(tm.RegisterTask(10*time.Second, every.inc))
+		must(t)(tm.RegisterTask(10*time.Second, unnamed.inc))
+		_ = tm.SetTaskOptions(idDaily, TaskOptions{Name: "daily", Misfire: tt.policy})
+		_ = tm.SetTaskOptions(idEvery, TaskOptions{Name: "every", Misfire: tt.policy})
+		tm.Start()
+		c.BlockUntil(3)
+		settle(tm)
+
+		if daily.get() != tt.cron || every.get() != tt.every || unnamed.get() != 0 {
+			t.Fatalf("policy %d: runs = %d, %d, %d, want %d, %d, 0",
+				tt.policy, daily.get(), every.get(), unnamed.get(), tt.cron, tt.every)
+		}
+		// 固定间隔任务从上次执行时间继续计时
+		if next, _ := tm.Next(idEvery); !next.Equal(start.Add(5 * time.Second)) {
+			t.Fatalf("policy %d: next = %v, want %v", tt.policy, next, start.Add(5*time.Second))
+		}
+		tick(tm, c, 3, 5)
+		if every.get() != tt.every+1 {
+			t.Fatalf("policy %d: runs after 5s = %d, want %d", tt.policy, every.get(), tt.every+1)
+		}
+		tm.Stop()
+
+		runs := make(map[string]time.Time)
+		data, _ = os.ReadFile(path)
+		if err := json.Unmarshal(data, &runs); err != nil {
+			t.Fatal(err)
+		}
+		if !runs["every"].Equal(start.Add(5 * time.Second)) {
+			t.Fatalf("policy %d: state = %v", tt.policy, runs)
+		}
+		if _, ok := runs[""]; ok || len(runs) != 2 {
+			t.Fatalf("policy %d: state = %v", tt.policy, runs)
+		}
+	}
+}
+
+// allWorkdays 每天都是工作日的日历
+type allWorkdays struct{}
+
+func (allWorkdays) IsWorkday(time.Time) bool { return true }
+
+func TestCalendar(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`
+# 国庆节
+2026-10-01~2026-10-07 休 国庆节
+2026-10-10 班 国庆节调休
+2026-10-31 workday
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 9, 0, 0, 0, time.Local) }
+	for d, want := range map[int]bool{1: false, 7: false, 8: true, 10: true, 11: false, 17: false, 19: true, 31: true} {
+		if got := cal.IsWorkday(day(d)); got != want {
+			t.Errorf("10-%02d IsWorkday = %v, want %v", d, got, want)
+		}
+	}
+	if name, ok := cal.Holiday(day(3)); !ok || name != "国庆节" {
+		t.Errorf("Holiday = %q, %v", name, ok)
+	}
+
+	for _, bad := range []string{"2026-10-01", "2026-13-01 休", "2026-10-07~2026-10-01 休", "2026-10-01 半天"} {
+		if _, err := ParseCalendar(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseCalendar(%q) 应返回错误", bad)
+		}
+	}
+
+	// 9月30日之后的工作日9点：跳过国庆假期和周末，10月10日调休上班
+	s := WorkdaysOnly(MustParseCron("0 9 * * *"), cal)
+	x := time.Date(2026, 9, 30, 10, 0, 0, 0, time.Local)
+	var got []int
+	for i := 0; i < 3; i++ {
+		x = s.Next(x)
+		got = append(got, x.Day())
+	}
+	if got[0] != 8 || got[1] != 9 || got[2] != 10 {
+		t.Errorf("WorkdaysOnly = %v, want [8 9 10]", got)
+	}
+	if x := NonWorkdaysOnly(MustParseCron("0 9 * * *"), cal).Next(day(8)); x.Day() != 11 {
+		t.Errorf("NonWorkdaysOnly = %v", x)
+	}
+
+	// 假期内的执行时间远多于一天，按天跳过而不是逐个跳过
+	holiday := time.Date(2026, 10, 2, 0, 0, 0, 0, time.Local)
+	for _, expr := range []string{"* * * * *", "* * * * * *"} {
+		if x := WorkdaysOnly(MustParseCron(expr), cal).Next(holiday); !x.Equal(time.Date(2026, 10, 8, 0, 0, 0, 0, time.Local)) {
+			t.Errorf("WorkdaysOnly(%s) = %v", expr, x)
+		}
+	}
+	from := holiday.Add(9 * time.Hour)
+	x = WorkdaysOnly(Every(7*time.Hour), cal).Next(from)
+	if x.Day() != 8 || x.Hour() >= 7 || x.Sub(from)%(7*time.Hour) != 0 {
+		t.Errorf("WorkdaysOnly(Every) = %v", x)
+	}
+	if x := NonWorkdaysOnly(MustParseCron("* * * * *"), allWorkdays{}).Next(holiday); !x.IsZero() {
+		t.Errorf("没有休息日时 Next = %v, want zero", x)
+	}
+
+	// 10月31日为周六但调休上班，是当月最后一个工作日；11月最后一个工作日为30日(周一)
+	last := LastWorkdayOfMonth(18, 0, cal)
+	if x := last.Next(day(19)); !x.Equal(time.Date(2026, 10, 31, 18, 0, 0, 0, time.Local)) {
+		t.Errorf("LastWorkdayOfMonth = %v", x)
+	}
+	if x := last.Next(time.Date(2026, 10, 31, 18, 0, 0, 0, time.Local)); !x.Equal(time.Date(2026, 11, 30, 18, 0, 0, 0, time.Local)) {
+		t.Errorf("LastWorkdayOfMonth = %v", x)
+	}
+	if x := FirstWorkdayOfMonth(9, 30, cal).Next(time.Date(2026, 9, 15, 0, 0, 0, 0, time.Local)); !x.Equal(time.Date(2026, 10, 8, 9, 30, 0, 0, time.Local)) {
+		t.Errorf("FirstWorkdayOfMonth = %v", x)
+	}
+	if x := LastWorkdayOfMonth(18, 0, nil).Next(day(19)); x.Day() != 30 {
+		t.Errorf("LastWorkdayOfMonth(Weekdays) = %v", x)
+	}
+}
+
+func TestSpread(t *testing.T) {
+	spread := 30 * time.Second
+	if HostOffset("agent-1", "sync", spread) != HostOffset("agent-1", "sync", spread) {
+		t.Fatal("HostOffset 结果不固定")
+	}
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		d := HostOffset("agent-"+strconv.Itoa(i), "sync", spread)
+		if d < 0 || d >= spread {
+			t.Fatalf("HostOffset = %v, 超出[0, %v)", d, spread)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 10 {
+		t.Fatalf("20台主机只有%d个不同的偏移", len(seen))
+	}
+
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	tm.SetHostname("agent-1")
+	runs := make(chan time.Time, 10)
+	id := must(t)(tm.RegisterTask(time.Minute, func() { runs <- c.Now() }))
+	must(t)(id, tm.SetTaskOptions(id, TaskOptions{Name: "sync", Spread: spread}))
+	tm.Start()
+	defer tm.Stop()
+
+	offset := (HostOffset("agent-1", "sync", spread) + time.Second - 1).Truncate(time.Second)
+	tick(tm, c, 1, 60+int(offset/time.Second)-1)
+	if len(runs) != 0 {
+		t.Fatal("run before offset")
+	}
+	tick(tm, c, 1, 1)
+	if len(runs) != 1 {
+		t.Fatal("no run after offset")
+	}
+	if got, want := <-runs, start.Add(time.Minute+offset); !got.Equal(want) {
+		t.Fatalf("run at %v, want %v", got, want)
+	}
+
+	// Jitter为随机值，只检查延迟范围
+	must(t)(id, tm.SetTaskOptions(id, TaskOptions{Jitter: 10 * time.Second}))
+	for i := 0; i < 20; i++ {
+		if d := tm.tasks[id].delay("agent-1"); d < 0 || d >= 10*time.Second {
+			t.Fatalf("jitter = %v", d)
+		}
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	tm := NewTickerManager()
+	tm.SetMaxConcurrent(2)
+	var mu sync.Mutex
+	running, peak := 0, 0
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	var ids []TaskID
+	for i := 0; i < 4; i++ {
+		ids = append(ids, must(t)(tm.RegisterTask(time.Hour, func() {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			started <- struct{}{}
+			<-release
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})))
+	}
+	tm.Start()
+	for _, id := range ids {
+		if err := tm.RunNow(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	<-started
+	select {
+	case <-started:
+		t.Fatal("超出并发上限")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-started
+	<-started
+	settle(tm)
+	tm.Stop()
+	if peak != 2 {
+		t.Fatalf("peak = %d, want 2", peak)
+	}
+}
+
+func TestNoMoreRuns(t *testing.T) {
+	c := common.NewFakeClock(start)
+	tm := newManager(c, JumpKeep)
+	var n counter
+	id := must(t)(tm.AddCron("0 0 30 2 *", func(context.Context) error { n.inc(); return nil }))
+	tm.Start()
+	defer tm.Stop()
+
+	tick(tm, c, 1, 5)
+	if got := n.get(); got != 0 {
+		t.Fatalf("runs = %d, want 0", got)
+	}
+	if next, err := tm.Next(id); err != nil || !next.IsZero() {
+		t.Fatalf("Next = %v, %v, want zero", next, err)
+	}
+	// 不再有执行时间的任务仍可手动执行
+	if err := tm.RunNow(id); err != nil {
+		t.Fatal(err)
+	}
+	settle(tm)
+	for n.get() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+}
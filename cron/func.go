@@ -0,0 +1,99 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// TaskFunc 任务函数，ctx在Stop、Remove或超时时被取消，返回的错误通过OnError报告并按选项重试
+type TaskFunc func(ctx context.Context) error
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// wrap 注册时检查fn和args是否匹配，并包装为TaskFunc，避免执行时才因参数不匹配panic
+// fn的第一个参数为context.Context且args中不包含该参数时自动传入ctx；最后一个返回值为error时作为执行结果
+func wrap(fn interface{}, args []interface{}) (TaskFunc, error) {
+	if len(args) == 0 {
+		switch f := fn.(type) {
+		case TaskFunc:
+			if f != nil {
+				return f, nil
+			}
+		case func(context.Context) error:
+			if f != nil {
+				return f, nil
+			}
+		case func():
+			if f != nil {
+				return func(context.Context) error { f(); return nil }, nil
+			}
+		}
+	}
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("任务必须是函数，实际为%T", fn)
+	}
+	ft := v.Type()
+
+	// 参数个数不含ctx时按自动传入ctx处理
+	withCtx := ft.NumIn() > 0 && ft.In(0) == contextType &&
+		(len(args) == ft.NumIn()-1 || ft.IsVariadic() && len(args) >= ft.NumIn()-2)
+	params := make([]reflect.Type, 0, ft.NumIn())
+	for i := 0; i < ft.NumIn(); i++ {
+		if i == 0 && withCtx {
+			continue
+		}
+		params = append(params, ft.In(i))
+	}
+
+	fixed := len(params)
+	if ft.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return nil, fmt.Errorf("函数%s至少需要%d个参数，实际为%d个", ft, fixed, len(args))
+		}
+	} else if len(args) != fixed {
+		return nil, fmt.Errorf("函数%s需要%d个参数，实际为%d个", ft, fixed, len(args))
+	}
+
+	values := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		want := params[min(i, len(params)-1)]
+		if i >= fixed {
+			want = want.Elem()
+		}
+		if arg == nil {
+			switch want.Kind() {
+			case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+				values[i] = reflect.Zero(want)
+				continue
+			}
+			return nil, fmt.Errorf("第%d个参数为nil，函数需要%s", i+1, want)
+		}
+		av := reflect.ValueOf(arg)
+		if !av.Type().AssignableTo(want) {
+			return nil, fmt.Errorf("第%d个参数类型为%s，函数需要%s", i+1, av.Type(), want)
+		}
+		values[i] = av
+	}
+
+	returnsErr := ft.NumOut() > 0 && ft.Out(ft.NumOut()-1) == errorType
+	return func(ctx context.Context) error {
+		in := values
+		if withCtx {
+			in = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, values...)
+		}
+		out := v.Call(in)
+		if returnsErr {
+			if e := out[len(out)-1]; !e.IsNil() {
+				return e.Interface().(error)
+			}
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,45 @@
+package cron
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler 返回任务列表的gin处理函数，输出Snapshot的JSON
+// 带 ?id= 参数时只返回对应任务，任务不存在时返回404；带 ?history=false 时不返回执行记录
+//
+//	r.GET("/debug/cron", tm.Handler())
+func (tm *TickerManager) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		withHistory := c.Query("history") != "false"
+		trim := func(s TaskStatus) TaskStatus {
+			if !withHistory {
+				s.History = nil
+			}
+			return s
+		}
+
+		if idStr := c.Query("id"); idStr != "" {
+			id, err := strconv.ParseUint(idStr, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "无效的任务编号: " + idStr})
+				return
+			}
+			s, err := tm.Status(TaskID(id))
+			if err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusOK, trim(s))
+			return
+		}
+
+		list := tm.Snapshot()
+		for i := range list {
+			list[i] = trim(list[i])
+		}
+		c.JSON(http.StatusOK, list)
+	}
+}
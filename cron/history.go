@@ -0,0 +1,153 @@
+package cron
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultHistorySize 每个任务默认保留的执行记录数
+const DefaultHistorySize = 10
+
+// Run 一次执行记录，重试时每次尝试单独记录
+type Run struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Attempt  int           `json:"attempt,omitempty"` // 第几次重试，首次执行为0
+	Err      error         `json:"-"`
+	Error    string        `json:"error,omitempty"` // Err的文本，便于序列化
+}
+
+// TaskStatus 任务状态快照
+type TaskStatus struct {
+	TaskInfo
+	Running int   `json:"running"` // 正在执行的次数
+	Total   int64 `json:"total"`   // 累计执行次数，含重试
+	Failed  int64 `json:"failed"`  // 累计失败次数，含panic、超时
+	Skipped int64 `json:"skipped"` // 因重叠跳过的次数
+	Last    *Run  `json:"last,omitempty"`
+	History []Run `json:"history"` // 最近的执行记录，按时间先后排列
+}
+
+// ring 固定容量的环形缓冲区
+type ring struct {
+	buf  []Run
+	next int
+	full bool
+}
+
+func (r *ring) add(run Run, size int) {
+	if size <= 0 {
+		return
+	}
+	if len(r.buf) != size {
+		// 容量变化时保留最近的记录
+		old := r.list()
+		if len(old) > size {
+			old = old[len(old)-size:]
+		}
+		r.buf = make([]Run, size)
+		r.next = copy(r.buf, old) % size
+		r.full = len(old) == size
+	}
+	r.buf[r.next] = run
+	r.next = (r.next + 1) % size
+	r.full = r.full || r.next == 0
+}
+
+// list 按时间先后返回记录
+func (r *ring) list() []Run {
+	if !r.full {
+		return append([]Run(nil), r.buf[:r.next]...)
+	}
+	return append(append([]Run(nil), r.buf[r.next:]...), r.buf[:r.next]...)
+}
+
+func (r *ring) last() *Run {
+	if !r.full && r.next == 0 {
+		return nil
+	}
+	run := r.buf[(r.next+len(r.buf)-1)%len(r.buf)]
+	return &run
+}
+
+// SetHistorySize 设置每个任务保留的执行记录数，默认DefaultHistorySize，为负数时不记录
+func (tm *TickerManager) SetHistorySize(n int) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.historySize = n
+}
+
+// OnStart 设置任务每次开始执行(含重试)时的回调
+func (tm *TickerManager) OnStart(fn func(id TaskID)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.onStart = fn
+}
+
+// OnSuccess 设置任务执行成功时的回调
+func (tm *TickerManager) OnSuccess(fn func(id TaskID, duration time.Duration)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.onSuccess = fn
+}
+
+// record 保存执行记录并更新计数
+func (tm *TickerManager) record(t *Task, run Run) {
+	tm.mu.Lock()
+	size := tm.historySize
+	tm.mu.Unlock()
+	if run.Err != nil {
+		run.Error = run.Err.Error()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case run.Err == ErrSkipped:
+		t.skipped++
+	case run.Err != nil:
+		t.total++
+		t.failed++
+	default:
+		t.total++
+	}
+	t.history.add(run, size)
+}
+
+func (t *Task) status(tm *TickerManager) TaskStatus {
+	info := t.info(tm.clock, tm.IsRunning)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return TaskStatus{
+		TaskInfo: info,
+		Running:  t.running,
+		Total:    t.total,
+		Failed:   t.failed,
+		Skipped:  t.skipped,
+		Last:     t.history.last(),
+		History:  t.history.list(),
+	}
+}
+
+// Status 查询单个任务的状态和执行记录
+func (tm *TickerManager) Status(id TaskID) (TaskStatus, error) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	t, ok := tm.tasks[id]
+	if !ok {
+		return TaskStatus{}, ErrTaskNotFound
+	}
+	return t.status(tm), nil
+}
+
+// Snapshot 按编号顺序返回所有任务的状态和执行记录
+func (tm *TickerManager) Snapshot() []TaskStatus {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	list := make([]TaskStatus, 0, len(tm.tasks))
+	for _, t := range tm.tasks {
+		list = append(list, t.status(tm))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
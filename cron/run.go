@@ -0,0 +1,217 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OverlapPolicy 上次执行尚未结束时又到执行时间的处理方式
+type OverlapPolicy int
+
+const (
+	// OverlapSkip 跳过本次执行，默认
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue 排队，上次执行结束后依次执行
+	OverlapQueue
+	// OverlapConcurrent 允许并发执行
+	OverlapConcurrent
+)
+
+// TaskOptions 任务的执行选项，零值为：不超时、重叠时跳过、不重试
+type TaskOptions struct {
+	// Name 任务名称，用于在状态文件中记录上次执行时间，同一管理器内应唯一，为空时不记录
+	// Name和Misfire在Start时生效
+	Name string
+	// Misfire 启动时补执行停止期间错过的执行的方式，需设置状态文件，见SetStateFile
+	Misfire MisfirePolicy
+	// Timeout 执行超时时间，超时后取消传给任务函数的ctx
+	// 无法强制终止不检查ctx的函数，超时后仍等待其返回，再按超时报告错误
+	Timeout time.Duration
+	// Overlap 上次执行尚未结束时的处理方式
+	Overlap OverlapPolicy
+	// MaxQueue OverlapQueue时最多排队的次数，超出后跳过，为0时不限制
+	MaxQueue int
+	// Retries 任务返回错误、panic或超时后的重试次数
+	Retries int
+	// RetryDelay 首次重试前的等待时间，之后每次翻倍，为0时使用1秒
+	RetryDelay time.Duration
+	// MaxRetryDelay 重试等待时间的上限，为0时不限制
+	MaxRetryDelay time.Duration
+	// Jitter 每次按计划执行前随机延迟[0, Jitter)，避免大量主机同时启动时同时执行
+	Jitter time.Duration
+	// Spread 每次按计划执行前按主机名和任务名(未设置Name时为任务编号)延迟[0, Spread)内的固定时间，见HostOffset
+	// Jitter和Spread应小于执行间隔，延迟期间到达的执行时间被跳过；RunNow不延迟
+	Spread time.Duration
+}
+
+var (
+	ErrTimeout = errors.New("任务执行超时")
+	ErrSkipped = errors.New("上次执行尚未结束，跳过本次执行")
+)
+
+// PanicError 任务panic时报告的错误
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("任务panic: %v", e.Value)
+}
+
+// SetTaskOptions 设置任务的执行选项，运行期间设置从下次执行开始生效
+func (tm *TickerManager) SetTaskOptions(id TaskID, opts TaskOptions) error {
+	t, err := tm.get(id)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.opts = opts
+	return nil
+}
+
+// OnError 设置任务出错时的回调，包括返回错误、panic、超时和因重叠跳过(ErrSkipped)，Stop或Remove取消的执行不报告
+// 未设置时使用logrus记录日志
+func (tm *TickerManager) OnError(fn func(id TaskID, err error)) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.onError = fn
+}
+
+func (tm *TickerManager) reportError(id TaskID, err error) {
+	tm.mu.Lock()
+	fn := tm.onError
+	tm.mu.Unlock()
+	if fn != nil {
+		fn(id, err)
+		return
+	}
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		logrus.Errorf("定时任务 %d 执行失败: %v\n%s", id, err, pe.Stack)
+		return
+	}
+	logrus.Errorf("定时任务 %d 执行失败: %v", id, err)
+}
+
+// dispatch 按重叠策略安排一次执行，ctx为任务协程的ctx
+func (tm *TickerManager) dispatch(t *Task, ctx context.Context) {
+	t.mu.Lock()
+	opts := t.opts
+	switch {
+	case t.running == 0 || opts.Overlap == OverlapConcurrent:
+		t.running++
+	case opts.Overlap == OverlapQueue && (opts.MaxQueue == 0 || t.queued < opts.MaxQueue):
+		t.queued++
+		t.mu.Unlock()
+		return
+	default:
+		t.mu.Unlock()
+		tm.record(t, Run{Start: tm.clock.Now(), Err: ErrSkipped})
+		tm.reportError(t.id, ErrSkipped)
+		return
+	}
+	t.mu.Unlock()
+
+	tm.wg.Add(1)
+	go func() {
+		defer tm.wg.Done()
+		for {
+			tm.execute(t, ctx)
+
+			t.mu.Lock()
+			if t.queued > 0 && ctx.Err() == nil {
+				t.queued--
+				t.mu.Unlock()
+				continue
+			}
+			t.queued = 0
+			t.running--
+			t.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// execute 执行一次任务，失败时按选项重试，ctx取消后不再报告错误和重试
+func (tm *TickerManager) execute(t *Task, ctx context.Context) {
+	t.mu.Lock()
+	opts := t.opts
+	t.mu.Unlock()
+
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	tm.mu.Lock()
+	onStart, onSuccess := tm.onStart, tm.onSuccess
+	tm.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		release, ok := tm.acquire(ctx)
+		if !ok {
+			return
+		}
+		if onStart != nil {
+			onStart(t.id)
+		}
+		start, mono := tm.clock.Now(), tm.clock.Monotonic()
+		if attempt == 0 {
+			tm.saveState(t.id, opts.Name, start)
+		}
+		err := t.invoke(ctx, opts.Timeout)
+		run := Run{Start: start, Duration: tm.clock.Monotonic() - mono, Attempt: attempt, Err: err}
+		release()
+		tm.record(t, run)
+		if err == nil {
+			if onSuccess != nil {
+				onSuccess(t.id, run.Duration)
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if attempt > 0 {
+			err = fmt.Errorf("第%d次重试: %w", attempt, err)
+		}
+		tm.reportError(t.id, err)
+		if attempt >= opts.Retries {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tm.clock.After(delay):
+		}
+		if delay *= 2; opts.MaxRetryDelay > 0 && delay > opts.MaxRetryDelay {
+			delay = opts.MaxRetryDelay
+		}
+	}
+}
+
+// invoke 调用任务函数，panic、返回的错误和超时均作为错误返回
+func (t *Task) invoke(ctx context.Context, timeout time.Duration) (err error) {
+	runCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+		if ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			err = errors.Join(ErrTimeout, err)
+		}
+	}()
+	return t.fn(runCtx)
+}
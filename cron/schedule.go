@@ -0,0 +1,458 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 计算任务的下次执行时间
+type Schedule interface {
+	// Next 返回t之后的下次执行时间，不再执行时返回零值
+	Next(t time.Time) time.Time
+}
+
+// Every 固定间隔执行，下次时间从上次执行结束时计算
+func Every(d time.Duration) Schedule {
+	if d < time.Second {
+		d = time.Second
+	}
+	return every(d)
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+var macros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron 解析cron表达式，时区为本地时区，表达式可使用 CRON_TZ=Asia/Shanghai 前缀指定时区
+//
+// 支持5个字段(分 时 日 月 周)或6个字段(秒 分 时 日 月 周)，以及
+// @yearly、@annually、@monthly、@weekly、@daily、@midnight、@hourly、@every 1h30m
+//
+// 字段支持 * ? , - / 和月份、星期的英文缩写，星期中0和7均表示周日，另外支持：
+//   - 日：L 当月最后一天，L-2 倒数第3天，15W 离15号最近的工作日(不跨月)，LW 当月最后一个工作日
+//   - 周：5L 当月最后一个周五，1#2 当月第二个周一
+//
+// 日和周同时指定(均不为*或?)时，满足任一即执行，与Vixie cron一致
+func ParseCron(expr string) (Schedule, error) {
+	return ParseCronInLocation(expr, time.Local)
+}
+
+// ParseCronInLocation 按指定时区解析cron表达式，表达式中的 CRON_TZ= 前缀优先
+func ParseCronInLocation(expr string, loc *time.Location) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	orig := expr
+	if strings.HasPrefix(expr, "CRON_TZ=") || strings.HasPrefix(expr, "TZ=") {
+		i := strings.IndexAny(expr, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("cron表达式缺少字段: %s", expr)
+		}
+		tz := expr[strings.IndexByte(expr, '=')+1 : i]
+		l, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("无效的时区 %s: %w", tz, err)
+		}
+		loc, expr = l, strings.TrimSpace(expr[i:])
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(expr[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("无效的间隔 %s: %w", expr, err)
+		}
+		return Every(d), nil
+	}
+	if m, ok := macros[expr]; ok {
+		expr = m
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron表达式需要5或6个字段: %s", expr)
+	}
+
+	s := &cronSchedule{loc: loc, expr: orig}
+	var err error
+	for i, b := range []struct {
+		field *uint64
+		r     bounds
+	}{{&s.second, seconds}, {&s.minute, minutes}, {&s.hour, hours}, {&s.month, months}} {
+		f := fields[[]int{0, 1, 2, 4}[i]]
+		if *b.field, err = parseField(f, b.r); err != nil {
+			return nil, fmt.Errorf("cron表达式 %s: %w", expr, err)
+		}
+	}
+	if err = s.parseDom(fields[3]); err != nil {
+		return nil, fmt.Errorf("cron表达式 %s: %w", expr, err)
+	}
+	if err = s.parseDow(fields[5]); err != nil {
+		return nil, fmt.Errorf("cron表达式 %s: %w", expr, err)
+	}
+	return s, nil
+}
+
+// MustParseCron 解析失败时panic，用于常量表达式
+func MustParseCron(expr string) Schedule {
+	s, err := ParseCron(expr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type bounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	seconds = bounds{0, 59, nil}
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	days    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	weekdays = bounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// parseField 解析逗号分隔的字段为位图
+func parseField(field string, r bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		b, err := parseRange(part, r)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parseRange 解析 * ? n a-b 及其 /step 形式
+func parseRange(expr string, r bounds) (uint64, error) {
+	rangePart, stepPart, hasStep := strings.Cut(expr, "/")
+	start, end, step := r.min, r.max, 1
+
+	if rangePart != "*" && rangePart != "?" {
+		lo, hi, isRange := strings.Cut(rangePart, "-")
+		var err error
+		if start, err = parseValue(lo, r); err != nil {
+			return 0, err
+		}
+		if isRange {
+			if end, err = parseValue(hi, r); err != nil {
+				return 0, err
+			}
+		} else if !hasStep {
+			end = start
+		}
+	}
+	if hasStep {
+		var err error
+		if step, err = strconv.Atoi(stepPart); err != nil || step <= 0 {
+			return 0, fmt.Errorf("无效的步长: %s", expr)
+		}
+	}
+	if start > end {
+		return 0, fmt.Errorf("无效的范围: %s", expr)
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits, nil
+}
+
+func parseValue(s string, r bounds) (int, error) {
+	if v, ok := r.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的值: %s", s)
+	}
+	if v < r.min || v > r.max {
+		return 0, fmt.Errorf("值 %d 超出范围 %d-%d", v, r.min, r.max)
+	}
+	return v, nil
+}
+
+// cronSchedule cron表达式，各字段以位图保存
+type cronSchedule struct {
+	second, minute, hour, month uint64
+	dom, dow                    uint64
+	domAny, dowAny              bool // 字段为*或?
+
+	lastDay     []int // L、L-n，保存n
+	nearest     []int // nW
+	lastWorkday bool  // LW
+	lastDow     uint64
+	nthDow      []struct{ dow, n int } // dow#n
+
+	loc  *time.Location
+	expr string
+}
+
+// String 原始表达式
+func (s *cronSchedule) String() string {
+	return s.expr
+}
+
+// Location 表达式使用的时区
+func (s *cronSchedule) Location() *time.Location {
+	return s.loc
+}
+
+func (s *cronSchedule) parseDom(field string) error {
+	if field == "*" || field == "?" {
+		s.domAny = true
+		s.dom = 1<<32 - 2 // 1~31
+		return nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case part == "LW":
+			s.lastWorkday = true
+		case part == "L" || strings.HasPrefix(part, "L-"):
+			n := 0
+			if part != "L" {
+				var err error
+				if n, err = strconv.Atoi(part[2:]); err != nil || n < 0 || n > 30 {
+					return fmt.Errorf("无效的日: %s", part)
+				}
+			}
+			s.lastDay = append(s.lastDay, n)
+		case strings.HasSuffix(part, "W"):
+			d, err := parseValue(strings.TrimSuffix(part, "W"), days)
+			if err != nil {
+				return err
+			}
+			s.nearest = append(s.nearest, d)
+		default:
+			b, err := parseRange(part, days)
+			if err != nil {
+				return err
+			}
+			s.dom |= b
+		}
+	}
+	return nil
+}
+
+func (s *cronSchedule) parseDow(field string) error {
+	if field == "*" || field == "?" {
+		s.dowAny = true
+		s.dow = 1<<7 - 1
+		return nil
+	}
+	for _, part := range strings.Split(field, ",") {
+		switch {
+		case strings.HasSuffix(part, "L") && len(part) > 1:
+			d, err := parseValue(strings.TrimSuffix(part, "L"), weekdays)
+			if err != nil {
+				return err
+			}
+			s.lastDow |= 1 << uint(d%7)
+		case strings.Contains(part, "#"):
+			ds, ns, _ := strings.Cut(part, "#")
+			d, err := parseValue(ds, weekdays)
+			if err != nil {
+				return err
+			}
+			n, err := strconv.Atoi(ns)
+			if err != nil || n < 1 || n > 5 {
+				return fmt.Errorf("无效的周序号: %s", part)
+			}
+			s.nthDow = append(s.nthDow, struct{ dow, n int }{d % 7, n})
+		default:
+			b, err := parseRange(part, weekdays)
+			if err != nil {
+				return err
+			}
+			if b&(1<<7) != 0 {
+				b = b&^(1<<7) | 1 // 7等同于0
+			}
+			s.dow |= b
+		}
+	}
+	return nil
+}
+
+// Next 返回t之后的下次执行时间，5年内没有满足条件的时间时返回零值
+// 按墙上时间匹配：夏令时跳过的时间顺延到跳变之后执行(如02:30变为03:30)，重复的时间只在第一次出现时执行
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc).Truncate(time.Second)
+	limit := t.Year() + 5
+
+	// 按天查找，使用中午避免夏令时影响日期计算
+	day := time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, s.loc)
+	for sameDay := true; day.Year() <= limit; sameDay = false {
+		if s.month&(1<<uint(day.Month())) == 0 {
+			day = time.Date(day.Year(), day.Month()+1, 1, 12, 0, 0, 0, s.loc)
+			continue
+		}
+		if s.dayMatches(day) {
+			if x, ok := s.nextInDay(day, t, sameDay); ok {
+				return x.In(origLoc)
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 12, 0, 0, 0, s.loc)
+	}
+	return time.Time{}
+}
+
+// nextInDay 返回day当天晚于t的第一个匹配时刻，sameDay为true时跳过当天早于t的墙上时间
+func (s *cronSchedule) nextInDay(day, t time.Time, sameDay bool) (time.Time, bool) {
+	var th, tm, ts int
+	if sameDay {
+		th, tm, ts = t.Clock()
+	}
+	for h := th; h < 24; h++ {
+		if s.hour&(1<<uint(h)) == 0 {
+			continue
+		}
+		m0 := 0
+		if h == th {
+			m0 = tm
+		}
+		for m := m0; m < 60; m++ {
+			if s.minute&(1<<uint(m)) == 0 {
+				continue
+			}
+			s0 := 0
+			if h == th && m == tm {
+				s0 = ts
+			}
+			for sec := s0; sec < 60; sec++ {
+				if s.second&(1<<uint(sec)) == 0 {
+					continue
+				}
+				if x := wallTime(day, h, m, sec, s.loc); x.After(t) {
+					return x, true
+				}
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// wallTime 返回day当天h:m:sec对应的时刻，重复的时间取第一次出现，跳过的时间顺延到跳变之后
+// time.Date对这两种情况的结果依赖时区实现，不能直接使用
+func wallTime(day time.Time, h, m, sec int, loc *time.Location) time.Time {
+	u := time.Date(day.Year(), day.Month(), day.Day(), h, m, sec, 0, time.UTC)
+	_, before := u.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := u.Add(24 * time.Hour).In(loc).Zone()
+
+	var x time.Time
+	for _, off := range []int{before, after} {
+		c := u.Add(-time.Duration(off) * time.Second).In(loc)
+		if ch, cm, cs := c.Clock(); ch == h && cm == m && cs == sec && c.Day() == day.Day() && (x.IsZero() || c.Before(x)) {
+			x = c
+		}
+	}
+	if x.IsZero() {
+		// 跳过的时间按跳变前的时差换算，即顺延跳变的长度
+		x = u.Add(-time.Duration(before) * time.Second).In(loc)
+	}
+	return x
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.domMatches(t)
+	dow := s.dowMatches(t)
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (s *cronSchedule) domMatches(t time.Time) bool {
+	day := t.Day()
+	if s.dom&(1<<uint(day)) != 0 {
+		return true
+	}
+	last := daysIn(t)
+	for _, n := range s.lastDay {
+		if day == last-n {
+			return true
+		}
+	}
+	for _, d := range s.nearest {
+		if day == nearestWeekday(t, d) {
+			return true
+		}
+	}
+	return s.lastWorkday && day == nearestWeekday(t, last)
+}
+
+func (s *cronSchedule) dowMatches(t time.Time) bool {
+	wd := uint(t.Weekday())
+	if s.dow&(1<<wd) != 0 {
+		return true
+	}
+	if s.lastDow&(1<<wd) != 0 && t.Day()+7 > daysIn(t) {
+		return true
+	}
+	for _, x := range s.nthDow {
+		if int(wd) == x.dow && (t.Day()-1)/7+1 == x.n {
+			return true
+		}
+	}
+	return false
+}
+
+// daysIn 当月天数
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// nearestWeekday 离当月day号最近的周一至周五，不跨月
+func nearestWeekday(t time.Time, day int) int {
+	last := daysIn(t)
+	if day > last {
+		day = last
+	}
+	switch time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return 3
+		}
+		return day - 1
+	case time.Sunday:
+		if day == last {
+			return day - 2
+		}
+		return day + 1
+	}
+	return day
+}
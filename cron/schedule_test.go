@@ -0,0 +1,125 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronNext(t *testing.T) {
+	// 2026-10-19为周一，时间均为UTC，DST用例在表达式中指定时区
+	tests := []struct {
+		expr string
+		from string
+		want []string // 依次调用Next的结果，空字符串表示零值
+	}{
+		{"*/15 * * * *", "2026-10-19T10:00:30Z", []string{"2026-10-19T10:15:00Z", "2026-10-19T10:30:00Z", "2026-10-19T10:45:00Z"}},
+		{"30 * * * * *", "2026-10-19T10:00:30Z", []string{"2026-10-19T10:01:30Z", "2026-10-19T10:02:30Z"}},
+		{"0 9-17/4 * * *", "2026-10-19T10:00:30Z", []string{"2026-10-19T13:00:00Z", "2026-10-19T17:00:00Z", "2026-10-20T09:00:00Z"}},
+		{"0 0 1 jan,JUL *", "2026-10-19T10:00:30Z", []string{"2027-01-01T00:00:00Z", "2027-07-01T00:00:00Z"}},
+
+		// 宏
+		{"@hourly", "2026-10-19T10:00:30Z", []string{"2026-10-19T11:00:00Z", "2026-10-19T12:00:00Z"}},
+		{"@daily", "2026-10-19T10:00:30Z", []string{"2026-10-20T00:00:00Z", "2026-10-21T00:00:00Z"}},
+		{"@midnight", "2026-10-19T10:00:30Z", []string{"2026-10-20T00:00:00Z"}},
+		{"@weekly", "2026-10-19T10:00:30Z", []string{"2026-10-25T00:00:00Z", "2026-11-01T00:00:00Z"}},
+		{"@monthly", "2026-10-19T10:00:30Z", []string{"2026-11-01T00:00:00Z", "2026-12-01T00:00:00Z"}},
+		{"@yearly", "2026-10-19T10:00:30Z", []string{"2027-01-01T00:00:00Z"}},
+		{"@annually", "2026-10-19T10:00:30Z", []string{"2027-01-01T00:00:00Z"}},
+		{"@every 90m", "2026-10-19T10:00:30Z", []string{"2026-10-19T11:30:30Z", "2026-10-19T13:00:30Z"}},
+
+		// 日
+		{"0 9 L * *", "2026-10-19T10:00:30Z", []string{"2026-10-31T09:00:00Z", "2026-11-30T09:00:00Z", "2026-12-31T09:00:00Z"}},
+		{"0 9 L * *", "2027-02-01T00:00:00Z", []string{"2027-02-28T09:00:00Z"}},
+		{"0 9 L-2 * *", "2026-10-19T10:00:30Z", []string{"2026-10-29T09:00:00Z", "2026-11-28T09:00:00Z"}},
+		{"0 9 15W * *", "2026-10-19T10:00:30Z", []string{"2026-11-16T09:00:00Z", "2026-12-15T09:00:00Z"}}, // 11-15为周日
+		{"0 9 1W * *", "2026-07-15T00:00:00Z", []string{"2026-08-03T09:00:00Z"}},                          // 08-01为周六，不跨月
+		{"0 9 31W * *", "2026-05-01T00:00:00Z", []string{"2026-05-29T09:00:00Z"}},                         // 05-31为周日，不跨月
+		{"0 18 LW * *", "2026-10-19T10:00:30Z", []string{"2026-10-30T18:00:00Z", "2026-11-30T18:00:00Z", "2026-12-31T18:00:00Z"}},
+
+		// 周
+		{"0 9 * * MON-FRI", "2026-10-23T10:00:00Z", []string{"2026-10-26T09:00:00Z", "2026-10-27T09:00:00Z"}},
+		{"0 0 * * 7", "2026-10-19T10:00:30Z", []string{"2026-10-25T00:00:00Z", "2026-11-01T00:00:00Z"}},
+		{"0 9 * * 5L", "2026-10-19T10:00:30Z", []string{"2026-10-30T09:00:00Z", "2026-11-27T09:00:00Z", "2026-12-25T09:00:00Z"}},
+		{"0 9 * * 1#2", "2026-10-19T10:00:30Z", []string{"2026-11-09T09:00:00Z", "2026-12-14T09:00:00Z"}},
+		{"0 9 ? * fri#5", "2026-10-19T10:00:30Z", []string{"2026-10-30T09:00:00Z", "2027-01-29T09:00:00Z"}},
+
+		// 日和周同时指定时满足任一，其中之一为*时需同时满足
+		{"0 9 1 * 1", "2026-10-19T10:00:30Z", []string{"2026-10-26T09:00:00Z", "2026-11-01T09:00:00Z", "2026-11-02T09:00:00Z"}},
+		{"0 9 1 * *", "2026-10-19T10:00:30Z", []string{"2026-11-01T09:00:00Z", "2026-12-01T09:00:00Z"}},
+		{"0 9 13 * 5", "2026-11-10T00:00:00Z", []string{"2026-11-13T09:00:00Z", "2026-11-20T09:00:00Z"}},
+
+		// 时区
+		{"CRON_TZ=Asia/Shanghai 0 9 * * *", "2026-10-19T10:00:30Z", []string{"2026-10-20T01:00:00Z", "2026-10-21T01:00:00Z"}},
+		{"TZ=Asia/Shanghai 0 0 9 * * *", "2026-10-19T00:30:00Z", []string{"2026-10-19T01:00:00Z"}},
+
+		// 夏令时开始，02:30不存在，顺延到03:30 EDT
+		{"CRON_TZ=America/New_York 30 2 * * *", "2026-03-07T17:00:00Z", []string{"2026-03-08T07:30:00Z", "2026-03-09T06:30:00Z"}},
+		{"CRON_TZ=Australia/Sydney 30 2 * * *", "2026-10-03T00:00:00Z", []string{"2026-10-03T16:30:00Z", "2026-10-04T15:30:00Z"}},
+		// 夏令时结束，01:30出现两次，只在第一次(EDT)执行
+		{"CRON_TZ=America/New_York 30 1 * * *", "2026-10-31T16:00:00Z", []string{"2026-11-01T05:30:00Z", "2026-11-02T06:30:00Z"}},
+		{"CRON_TZ=America/New_York 0 * * * *", "2026-11-01T04:30:00Z", []string{"2026-11-01T05:00:00Z", "2026-11-01T07:00:00Z", "2026-11-01T08:00:00Z"}},
+		{"CRON_TZ=Australia/Sydney 30 2 * * *", "2026-04-04T00:00:00Z", []string{"2026-04-04T15:30:00Z", "2026-04-05T16:30:00Z"}},
+
+		// 不存在的日期
+		{"0 0 30 2 *", "2026-10-19T10:00:30Z", []string{""}},
+	}
+
+	for _, tt := range tests {
+		s, err := ParseCronInLocation(tt.expr, time.UTC)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		x, err := time.Parse(time.RFC3339, tt.from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, w := range tt.want {
+			x = s.Next(x)
+			var want time.Time
+			if w != "" {
+				want, _ = time.Parse(time.RFC3339, w)
+			}
+			if !x.Equal(want) {
+				t.Errorf("%s from %s: Next #%d = %v, want %v", tt.expr, tt.from, i+1, x.UTC(), want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * L-31 * *",
+		"* * 32W * *",
+		"* * * * 1#6",
+		"* * * * 9L",
+		"@every x",
+		"CRON_TZ=Nowhere/City * * * * *",
+		"CRON_TZ=UTC",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) 应返回错误", expr)
+		}
+	}
+}
+
+func TestScheduleString(t *testing.T) {
+	for _, expr := range []string{"CRON_TZ=Asia/Shanghai 0 9 * * *", "@daily", "@every 1h0m0s"} {
+		if s := MustParseCron(expr); s.(interface{ String() string }).String() != expr {
+			t.Errorf("String() = %v, want %s", s, expr)
+		}
+	}
+}
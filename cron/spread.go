@@ -0,0 +1,97 @@
+package cron
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+)
+
+// HostOffset 根据主机名和key计算[0, spread)内的固定偏移，同一主机上的同一任务每次结果相同，不同主机相互错开
+func HostOffset(host, key string, spread time.Duration) time.Duration {
+	if spread <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(host))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return time.Duration(h.Sum64() % uint64(spread))
+}
+
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+// SetHostname 设置计算Spread偏移使用的主机名，默认为os.Hostname()，容器中主机名相同时可改用实例编号等，需在Start之前调用
+func (tm *TickerManager) SetHostname(name string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.host = name
+}
+
+// SetMaxConcurrent 设置管理器内同时执行的任务数上限，超出时等待其他任务执行结束，为0时不限制
+// 运行期间设置时，已在执行的任务不受影响
+func (tm *TickerManager) SetMaxConcurrent(n int) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if n <= 0 {
+		tm.sem = nil
+		return
+	}
+	tm.sem = make(chan struct{}, n)
+}
+
+// acquire 获取执行名额，ctx取消时返回false
+func (tm *TickerManager) acquire(ctx context.Context) (release func(), ok bool) {
+	tm.mu.Lock()
+	sem := tm.sem
+	tm.mu.Unlock()
+	if sem == nil {
+		return func() {}, true
+	}
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// delay 按计划执行前的延迟，为Spread偏移加随机的Jitter
+func (t *Task) delay(host string) time.Duration {
+	t.mu.Lock()
+	opts := t.opts
+	t.mu.Unlock()
+
+	var d time.Duration
+	if opts.Spread > 0 {
+		key := opts.Name
+		if key == "" {
+			key = strconv.FormatUint(uint64(t.id), 10)
+		}
+		d = HostOffset(host, key, opts.Spread)
+	}
+	if opts.Jitter > 0 {
+		d += rand.N(opts.Jitter)
+	}
+	return d
+}
+
+// sleep 在任务协程中等待d，期间仍响应手动执行，ctx取消时返回false
+func (tm *TickerManager) sleep(t *Task, ctx context.Context, d time.Duration) bool {
+	timer := tm.clock.After(d)
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.trigger:
+			tm.dispatch(t, ctx)
+		case <-timer:
+			return true
+		}
+	}
+}
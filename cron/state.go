@@ -0,0 +1,147 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mzky/utils/common"
+	"github.com/sirupsen/logrus"
+)
+
+// MisfirePolicy 启动时发现任务在停止期间错过了执行的处理方式，只对设置了Name且有状态记录的任务生效
+type MisfirePolicy int
+
+const (
+	// MisfireRunOnce 错过一次或多次都只补执行一次，默认
+	MisfireRunOnce MisfirePolicy = iota
+	// MisfireRunAll 按错过的次数依次补执行，最多MaxMisfireRuns次
+	MisfireRunAll
+	// MisfireSkip 不补执行
+	MisfireSkip
+)
+
+// MaxMisfireRuns MisfireRunAll时最多补执行的次数
+var MaxMisfireRuns = 100
+
+// stateFile 保存各任务上次执行时间的状态文件
+type stateFile struct {
+	mu   sync.Mutex
+	path string
+	runs map[string]time.Time // 任务名称 -> 上次开始执行的时间
+}
+
+// SetStateFile 设置状态文件并读取其中的上次执行时间，文件不存在时视为空
+// 设置了Name的任务每次开始执行时更新状态文件，Start时按任务的MisfirePolicy补执行停止期间错过的执行
+// 固定间隔的任务从上次执行时间继续计时，而不是从启动时重新计时
+func (tm *TickerManager) SetStateFile(path string) error {
+	runs := make(map[string]time.Time)
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err = json.Unmarshal(data, &runs); err != nil {
+			return fmt.Errorf("状态文件 %s 格式错误: %w", path, err)
+		}
+	}
+
+	tm.state.mu.Lock()
+	defer tm.state.mu.Unlock()
+	tm.state.path, tm.state.runs = path, runs
+	return nil
+}
+
+// lastRun 任务的上次执行时间
+func (s *stateFile) lastRun(name string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || name == "" {
+		return time.Time{}, false
+	}
+	t, ok := s.runs[name]
+	return t, ok
+}
+
+// save 记录任务的执行时间并原子写入状态文件
+func (s *stateFile) save(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.path == "" || name == "" {
+		return nil
+	}
+	s.runs[name] = t
+	data, err := json.MarshalIndent(s.runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return common.WriteFileAtomic(s.path, data, 0644)
+}
+
+func (tm *TickerManager) saveState(id TaskID, name string, start time.Time) {
+	if err := tm.state.save(name, start); err != nil {
+		logrus.Errorf("定时任务 %d 保存状态文件失败: %v", id, err)
+	}
+}
+
+// misfires 根据上次执行时间计算停止期间错过的执行次数，并让固定间隔任务从上次执行时间继续计时
+// 需持有t.mu，在reset之后调用
+func (t *Task) misfires(c common.Clock, last time.Time) int {
+	now := c.Now()
+	if !last.Before(now) {
+		return 0
+	}
+
+	n := 0
+	if t.schedule != nil {
+		for x := t.schedule.Next(last); !x.IsZero() && !x.After(now) && n < MaxMisfireRuns; x = t.schedule.Next(x) {
+			n++
+		}
+	} else {
+		elapsed := now.Sub(last)
+		n = int(min(elapsed/t.interval, time.Duration(MaxMisfireRuns)))
+		t.nextMono = c.Monotonic() + t.interval - elapsed%t.interval
+	}
+
+	switch t.opts.Misfire {
+	case MisfireSkip:
+		return 0
+	case MisfireRunAll:
+		return n
+	default:
+		return min(n, 1)
+	}
+}
+
+// catchUp 按Jitter和Spread延迟后依次补执行n次，遵循重叠策略计数，Stop或Remove后不再继续，需持有tm.mu
+func (tm *TickerManager) catchUp(t *Task, ctx context.Context, n int) {
+	if n <= 0 {
+		return
+	}
+	delay := t.delay(tm.host)
+	t.mu.Lock()
+	t.running++
+	t.mu.Unlock()
+
+	tm.wg.Add(1)
+	go func() {
+		defer tm.wg.Done()
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+			case <-tm.clock.After(delay):
+			}
+		}
+		for i := 0; i < n && ctx.Err() == nil; i++ {
+			tm.execute(t, ctx)
+		}
+		t.mu.Lock()
+		t.running--
+		t.mu.Unlock()
+	}()
+}
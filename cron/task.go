@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mzky/utils/common"
+)
+
+// TaskID 任务编号，注册时分配，同一TickerManager内唯一
+type TaskID uint64
+
+// Task 定义一个定时任务
+type Task struct {
+	id       TaskID
+	interval time.Duration
+	schedule Schedule // 不为nil时按schedule计算执行时间，否则按interval固定间隔执行
+	fn       TaskFunc
+	trigger  chan struct{} // RunNow
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc // 取消任务协程及正在执行的ctx，Remove、Stop时调用
+	opts     TaskOptions
+	running  int // 正在执行的次数
+	queued   int // OverlapQueue排队等待的次数
+	paused   bool
+	next     time.Time     // cron任务的下次执行时间(墙上时间)
+	nextMono time.Duration // 固定间隔任务的下次执行时间(单调时间)
+	epoch    uint64        // 已处理的系统时间跳变次数
+
+	history ring
+	total   int64
+	failed  int64
+	skipped int64
+}
+
+// TaskInfo 任务信息快照
+type TaskInfo struct {
+	ID       TaskID    `json:"id"`
+	Name     string    `json:"name,omitempty"`
+	Schedule string    `json:"schedule"` // 执行计划，如 "@every 1m0s"、"0 9 * * 1-5"
+	Paused   bool      `json:"paused"`
+	Next     time.Time `json:"next"` // 下次执行时间，管理器未启动或不再执行时为零值
+}
+
+func (t *Task) String() string {
+	if t.schedule != nil {
+		return fmt.Sprint(t.schedule)
+	}
+	return "@every " + t.interval.String()
+}
+
+func (t *Task) info(c common.Clock, running bool) TaskInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	info := TaskInfo{ID: t.id, Name: t.opts.Name, Schedule: t.String(), Paused: t.paused}
+	if !running {
+		return info
+	}
+	if t.schedule != nil {
+		info.Next = t.next
+	} else {
+		info.Next = c.Now().Add(t.nextMono - c.Monotonic())
+	}
+	return info
+}
+
+// reset 启动时计算首次执行时间
+func (t *Task) reset(c common.Clock, epoch uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.epoch = epoch
+	if t.schedule != nil {
+		t.next = t.schedule.Next(c.Now())
+	} else {
+		t.nextMono = c.Monotonic() + t.interval
+	}
+}
+
+// wait 距离下次执行的时间，不再执行时返回false
+func (t *Task) wait(c common.Clock) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.schedule != nil {
+		return t.next.Sub(c.Now()), !t.next.IsZero()
+	}
+	return t.nextMono - c.Monotonic(), true
+}
+
+// due 是否需要执行，到期时计算下次执行时间，暂停的任务到期也不执行
+// cron任务检测到系统时间跳变时返回跳变量，策略为JumpRecompute时重新计算下次执行时间
+func (t *Task) due(tm *TickerManager) (run bool, jump time.Duration) {
+	c := tm.clock
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.schedule == nil {
+		now := c.Monotonic()
+		if now < t.nextMono {
+			return false, 0
+		}
+		for t.nextMono <= now {
+			t.nextMono += t.interval
+		}
+		return !t.paused, 0
+	}
+
+	now := c.Now()
+	epoch, jump := tm.jump.check(c)
+	if epoch != t.epoch {
+		t.epoch = epoch
+		if tm.jumpPolicy == JumpRecompute {
+			t.next = t.schedule.Next(now)
+			return false, jump
+		}
+	}
+	if t.next.IsZero() || now.Before(t.next) {
+		// 零值表示不再执行
+		return false, jump
+	}
+	t.next = t.schedule.Next(now)
+	return !t.paused, jump
+}
+
+// stop 取消任务协程及正在执行的ctx
+func (t *Task) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+func (t *Task) setPaused(paused bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.paused = paused
+}
@@ -0,0 +1,166 @@
+// Package fsutil 打包、解包共用的文件遍历和路径检查，供common和archive使用
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafePath 解压路径或符号链接逃逸出解压目录
+var ErrUnsafePath = errors.New("不安全的解压路径")
+
+// Walk 遍历files，回调每个文件或目录及其相对base的路径(使用/分隔)，base为空时以各文件所在目录为基准
+// 目录会递归遍历，fn返回filepath.SkipDir时跳过该目录
+func Walk(base string, files []string, fn func(fp, name string, fi fs.FileInfo) error) error {
+	for _, file := range files {
+		root := base
+		if root == "" {
+			root = filepath.Dir(file)
+		}
+		err := filepath.Walk(file, func(fp string, fi fs.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, fp)
+			if err != nil {
+				return err
+			}
+			if rel == "." {
+				return nil
+			}
+			if escapes(rel) {
+				return fmt.Errorf("文件 %s 不在目录 %s 下", fp, root)
+			}
+			return fn(fp, filepath.ToSlash(rel), fi)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Join 将包内路径拼接到dest下，路径逃逸出dest或上级目录中有已存在的符号链接时返回ErrUnsafePath
+// 只按字符串检查不够：先解压的符号链接(如 l1 -> . 和 l1/l2 -> ..)可使后续条目写到dest之外
+func Join(dest, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("%w: 非法的绝对路径 %s", ErrUnsafePath, name)
+	}
+	fp := filepath.Join(dest, name)
+	if !Within(dest, fp) {
+		return "", fmt.Errorf("%w: 路径 %s 逃逸出解压目录", ErrUnsafePath, name)
+	}
+	if err := checkParents(dest, fp); err != nil {
+		return "", err
+	}
+	return fp, nil
+}
+
+// checkParents 逐级检查dest与fp之间已存在的目录，其中有符号链接时返回ErrUnsafePath
+func checkParents(dest, fp string) error {
+	rel, err := filepath.Rel(dest, filepath.Dir(fp))
+	if err != nil || rel == "." {
+		return err
+	}
+	cur := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		cur = filepath.Join(cur, part)
+		fi, err := os.Lstat(cur)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("%w: 上级目录 %s 是符号链接", ErrUnsafePath, cur)
+		}
+	}
+	return nil
+}
+
+func isSymlink(fp string) bool {
+	fi, err := os.Lstat(fp)
+	return err == nil && fi.Mode()&fs.ModeSymlink != 0
+}
+
+// Within 按路径判断fp是否位于dest内
+func Within(dest, fp string) bool {
+	rel, err := filepath.Rel(dest, fp)
+	return err == nil && !escapes(rel)
+}
+
+// escapes 相对路径是否指向上级目录之外
+func escapes(rel string) bool {
+	return rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// WriteFile 将r写入fp，自动创建上级目录，返回写入的字节数，fp为已存在的符号链接时返回ErrUnsafePath
+// 创建或写入fp失败时返回*fs.PathError，读取r失败时原样返回r的错误
+func WriteFile(fp string, r io.Reader, mode fs.FileMode) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return 0, err
+	}
+	if isSymlink(fp) {
+		return 0, fmt.Errorf("%w: %s 是符号链接", ErrUnsafePath, fp)
+	}
+	// 检查后仍可能被替换为符号链接，支持时以O_NOFOLLOW打开
+	w, err := os.OpenFile(fp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|oNoFollow, mode.Perm())
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// Symlink 在fp创建指向target的符号链接，fp已存在时先删除，fp应由Join得到
+// target必须是相对路径且指向dest内，且不能经过已存在的符号链接，否则返回ErrUnsafePath
+func Symlink(dest, fp, target string) error {
+	link := filepath.FromSlash(target)
+	if filepath.IsAbs(link) || filepath.VolumeName(link) != "" || !linkWithin(dest, filepath.Dir(fp), link) {
+		return fmt.Errorf("%w: 符号链接 %s 指向解压目录之外 %s", ErrUnsafePath, fp, target)
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), os.ModePerm); err != nil {
+		return err
+	}
+	_ = os.Remove(fp)
+	return os.Symlink(link, fp)
+}
+
+// linkWithin 从dir开始逐级解析链接目标，经过符号链接后继续解析(如 l1/.. 中l1为链接)或逃逸出dest时返回false
+func linkWithin(dest, dir, link string) bool {
+	cur := dir
+	for i, part := range strings.Split(link, string(filepath.Separator)) {
+		if part == "" || part == "." {
+			continue
+		}
+		if i > 0 && isSymlink(cur) {
+			return false
+		}
+		if part == ".." {
+			cur = filepath.Dir(cur)
+		} else {
+			cur = filepath.Join(cur, part)
+		}
+		if !Within(dest, cur) {
+			return false
+		}
+	}
+	return true
+}
+
+// NopWriteCloser Close不做任何操作的io.WriteCloser
+type NopWriteCloser struct {
+	io.Writer
+}
+
+func (NopWriteCloser) Close() error { return nil }
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package fsutil
+
+// 不支持O_NOFOLLOW的平台只在打开前检查符号链接
+const oNoFollow = 0
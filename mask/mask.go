@@ -0,0 +1,130 @@
+package mask
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Char 脱敏使用的替换字符
+var Char = "*"
+
+// Rule 脱敏规则
+type Rule func(string) string
+
+var (
+	rulesMu sync.RWMutex
+	rules   = map[string]Rule{
+		"mobile":   Mobile,
+		"idcard":   IDCard,
+		"bankcard": BankCard,
+		"email":    Email,
+		"ip":       IP,
+		"name":     Name,
+		"all":      All,
+	}
+)
+
+// Register 注册自定义规则，可在结构体标签中按名称使用，同名规则会被覆盖
+func Register(name string, rule Rule) {
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	rules[name] = rule
+}
+
+// Lookup 按名称查找规则，支持"keep=前缀,后缀"形式，如"keep=3,4"
+func Lookup(name string) (Rule, bool) {
+	if s, ok := strings.CutPrefix(name, "keep="); ok {
+		var prefix, suffix int
+		if _, err := fmt.Sscanf(s, "%d,%d", &prefix, &suffix); err != nil {
+			return nil, false
+		}
+		return Keep(prefix, suffix), true
+	}
+
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	rule, ok := rules[name]
+	return rule, ok
+}
+
+// Keep 保留前prefix个和后suffix个字符的规则
+func Keep(prefix, suffix int) Rule {
+	return func(s string) string {
+		return Mask(s, prefix, suffix)
+	}
+}
+
+// Mask 保留前prefix个和后suffix个字符，其余替换为Char，按字符而非字节计算
+// 字符串过短时缩减保留的字符数，保证至少替换一个字符
+func Mask(s string, prefix, suffix int) string {
+	r := []rune(s)
+	n := len(r)
+	if n == 0 {
+		return s
+	}
+	prefix, suffix = max(prefix, 0), max(suffix, 0)
+	for prefix+suffix >= n {
+		if suffix >= prefix && suffix > 0 {
+			suffix--
+		} else {
+			prefix--
+		}
+	}
+	return string(r[:prefix]) + strings.Repeat(Char, n-prefix-suffix) + string(r[n-suffix:])
+}
+
+// All 全部替换
+func All(s string) string {
+	return strings.Repeat(Char, len([]rune(s)))
+}
+
+// Mobile 手机号，保留前3位和后4位，如138****5678，带+86等前缀时前缀一并保留
+func Mobile(s string) string {
+	return Mask(s, max(len(s)-8, 3), 4)
+}
+
+// IDCard 居民身份证号，保留前3位和后4位
+func IDCard(s string) string {
+	return Mask(s, 3, 4)
+}
+
+// BankCard 银行卡号，保留前6位发卡行标识和后4位
+func BankCard(s string) string {
+	return Mask(s, 6, 4)
+}
+
+// Email 邮箱，用户名保留首字符，域名不脱敏，如z*******@example.com
+func Email(s string) string {
+	at := strings.LastIndex(s, "@")
+	if at < 0 {
+		return Mask(s, 1, 0)
+	}
+	return Mask(s[:at], 1, 0) + s[at:]
+}
+
+// IP IPv4隐藏后两段，如192.168.*.*，IPv6隐藏后四组
+func IP(s string) string {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return Mask(s, 2, 2)
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%s.%s", v4[0], v4[1], Char, Char)
+	}
+	groups := make([]string, 0, 8)
+	for i := 0; i < 8; i++ {
+		if i < 4 {
+			groups = append(groups, fmt.Sprintf("%x", uint16(ip[2*i])<<8|uint16(ip[2*i+1])))
+		} else {
+			groups = append(groups, Char)
+		}
+	}
+	return strings.Join(groups, ":")
+}
+
+// Name 姓名，仅保留第一个字，如张**
+func Name(s string) string {
+	return Mask(s, 1, 0)
+}
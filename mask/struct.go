@@ -0,0 +1,123 @@
+package mask
+
+import (
+	"encoding/json"
+	"reflect"
+	"unsafe"
+)
+
+// 结构体字段标签名，如 `mask:"mobile"`、`mask:"keep=3,4"`
+const tagName = "mask"
+
+// 嵌套层数上限，避免循环引用导致无限递归
+const maxDepth = 32
+
+// Struct 返回v的脱敏副本，按字段的mask标签处理字符串字段，支持嵌套结构体、指针、切片和map，不修改v
+// 标签作用于切片、map的字符串元素；未知的规则名按All处理
+func Struct[T any](v T) T {
+	src := reflect.ValueOf(&v).Elem()
+	if src.Kind() == reflect.Interface && src.IsNil() {
+		return v // 如 JSON(nil)
+	}
+	dst := maskValue(src, nil, 0)
+	return dst.Interface().(T)
+}
+
+// JSON 脱敏后序列化为json
+func JSON(v any) ([]byte, error) {
+	return json.Marshal(Struct(v))
+}
+
+func maskValue(v reflect.Value, rule Rule, depth int) reflect.Value {
+	if depth > maxDepth {
+		return v
+	}
+	depth++
+
+	switch v.Kind() {
+	case reflect.String:
+		if rule == nil {
+			return v
+		}
+		return reflect.ValueOf(rule(v.String())).Convert(v.Type())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type().Elem())
+		n.Elem().Set(maskValue(v.Elem(), rule, depth))
+		return n
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.New(v.Type()).Elem()
+		n.Set(maskValue(v.Elem(), rule, depth))
+		return n
+	case reflect.Struct:
+		n := reflect.New(v.Type()).Elem()
+		n.Set(v)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.IsExported() {
+				n.Field(i).Set(maskValue(v.Field(i), fieldRule(f), depth))
+				continue
+			}
+			if embeddedStruct(f) {
+				// 未导出的嵌入结构体，其导出字段会被json提升序列化，同样需要脱敏
+				// reflect不允许设置未导出字段，通过字段地址得到可设置的副本
+				fv := reflect.NewAt(f.Type, unsafe.Pointer(n.Field(i).UnsafeAddr())).Elem()
+				fv.Set(maskValue(fv, nil, depth))
+			}
+		}
+		return n
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(maskValue(v.Index(i), rule, depth))
+		}
+		return n
+	case reflect.Array:
+		n := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			n.Index(i).Set(maskValue(v.Index(i), rule, depth))
+		}
+		return n
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		n := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			n.SetMapIndex(iter.Key(), maskValue(iter.Value(), rule, depth))
+		}
+		return n
+	default:
+		return v
+	}
+}
+
+// embeddedStruct 字段是否为嵌入的结构体或结构体指针
+func embeddedStruct(f reflect.StructField) bool {
+	t := f.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return f.Anonymous && t.Kind() == reflect.Struct
+}
+
+func fieldRule(f reflect.StructField) Rule {
+	name, ok := f.Tag.Lookup(tagName)
+	if !ok || name == "" || name == "-" {
+		return nil
+	}
+	if rule, ok := Lookup(name); ok {
+		return rule
+	}
+	return All
+}
@@ -0,0 +1,87 @@
+package mask
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type base struct {
+	Phone string `mask:"mobile"`
+}
+
+type card struct {
+	Card string `mask:"bankcard"`
+}
+
+type Contact struct {
+	Email string `mask:"email"`
+}
+
+type maskUser struct {
+	base
+	*card
+	*Contact
+	Name     string `mask:"name"`
+	Plain    string
+	IDs      []string          `mask:"idcard"`
+	Extra    map[string]string `mask:"keep=1,1"`
+	Friend   *maskUser
+	Contacts []Contact
+	secret   string
+}
+
+func TestStruct(t *testing.T) {
+	u := maskUser{
+		base:     base{Phone: "13812345678"},
+		card:     &card{Card: "6222021234567890"},
+		Contact:  &Contact{Email: "zhang@example.com"},
+		Name:     "张三",
+		Plain:    "plain",
+		IDs:      []string{"110105194912310021"},
+		Extra:    map[string]string{"k": "abcd"},
+		Friend:   &maskUser{Name: "李四", base: base{Phone: "13900001111"}},
+		Contacts: []Contact{{Email: "li@example.com"}},
+		secret:   "keep",
+	}
+	m := Struct(u)
+
+	tests := []struct{ name, got, want string }{
+		{"embedded", m.Phone, "138****5678"},
+		{"embedded pointer", m.Email, "z****@example.com"},
+		{"unexported embedded pointer", m.Card, "622202******7890"},
+		{"field", m.Name, "张*"},
+		{"untagged", m.Plain, "plain"},
+		{"slice", m.IDs[0], "110***********0021"},
+		{"map", m.Extra["k"], "a**d"},
+		{"pointer", m.Friend.Name, "李*"},
+		{"nested embedded", m.Friend.Phone, "139****1111"},
+		{"slice of struct", m.Contacts[0].Email, "l*@example.com"},
+		{"unexported", m.secret, "keep"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// 不修改原值
+	if u.Phone != "13812345678" || u.Card != "6222021234567890" || u.Email != "zhang@example.com" || u.Friend.Name != "李四" || u.IDs[0] != "110105194912310021" || u.Extra["k"] != "abcd" {
+		t.Errorf("原值被修改: %+v", u)
+	}
+
+	b, err := JSON(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err = json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["Phone"] != "138****5678" || out["Email"] != "z****@example.com" {
+		t.Errorf("JSON = %s", b)
+	}
+
+	if b, err = JSON(nil); err != nil || string(b) != "null" {
+		t.Errorf("JSON(nil) = %s, %v", b, err)
+	}
+}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"strconv"
 	"strings"
+
+	"github.com/mzky/utils/convert"
 )
 
 type DB struct {
@@ -129,34 +130,16 @@ func ToSlice[T any](iFace interface{}) SliceOf[T] {
 	return result
 }
 
-// ToFloat64 将interface{}转换为float64类型
+// ToFloat64 将interface{}转换为float64类型，无法转换时返回0，需要错误信息请使用convert.ToFloat64
 func ToFloat64(iFace interface{}) float64 {
-	v := reflect.ValueOf(iFace)
-	switch v.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return float64(iFace.(int))
-	case reflect.Float32, reflect.Float64:
-		return v.Float()
-	case reflect.String:
-		f, _ := strconv.ParseFloat(iFace.(string), 64)
-		return f
-	default:
-		return 0
-	}
+	f, _ := convert.ToFloat64(iFace)
+	return f
 }
 
-// ToString 将interface{}转换为string类型
+// ToString 将interface{}转换为string类型，无法转换时返回""，需要错误信息请使用convert.ToString
 func ToString(iFace interface{}) string {
-	v := reflect.ValueOf(iFace)
-	switch v.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
-		reflect.Float32, reflect.Float64, reflect.String:
-		return fmt.Sprintf("%v", iFace)
-	default:
-		return ""
-	}
+	s, _ := convert.ToString(iFace)
+	return s
 }
 
 func (db *DB) GetMap(key string) map[string]interface{} {
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package net
+
+import (
+	"errors"
+	"fmt"
+)
+
+// GetRealAdapter 获取物理网卡的永久MAC地址依赖Linux的ethtool ioctl，其他平台返回errors.ErrUnsupported
+func GetRealAdapter() ([]ItfNet, error) {
+	return nil, fmt.Errorf("获取物理网卡MAC地址仅支持Linux: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,36 @@
+package net
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mzky/utils/common"
+)
+
+// NewSnowflakeByMAC 使用网卡物理MAC地址的低10位作为机器号创建common.Snowflake
+func NewSnowflakeByMAC(epoch time.Time) (*common.Snowflake, error) {
+	worker, err := WorkerIDFromMAC()
+	if err != nil {
+		return nil, err
+	}
+	return common.NewSnowflake(epoch, worker)
+}
+
+// WorkerIDFromMAC 取第一块物理网卡MAC地址的低10位作为Snowflake机器号
+// 同一网段的机器MAC低位通常不同，但不保证唯一，集群规模较大时应统一分配机器号
+func WorkerIDFromMAC() (int64, error) {
+	adapters, err := GetRealAdapter()
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range adapters {
+		mac, err := hex.DecodeString(strings.NewReplacer(":", "", "-", "").Replace(a.MacAddress))
+		if err != nil || len(mac) < 2 {
+			continue
+		}
+		return (int64(mac[len(mac)-2])<<8 | int64(mac[len(mac)-1])) & common.MaxSnowflakeWorker, nil
+	}
+	return 0, errors.New("未找到物理网卡")
+}
@@ -0,0 +1,401 @@
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// CharClass 密码字符类别，可按位组合
+type CharClass uint8
+
+const (
+	Lower CharClass = 1 << iota
+	Upper
+	Digit
+	Symbol
+
+	AllClasses = Lower | Upper | Digit | Symbol
+)
+
+const (
+	lowerChars = "abcdefghijklmnopqrstuvwxyz"
+	upperChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars = "0123456789"
+	// DefaultSymbols 默认的特殊字符，不含引号、反斜杠和空格，避免在shell、配置文件中需要转义
+	DefaultSymbols = "!@#$%^&*-_=+?.,:;~"
+	// AmbiguousChars 容易看错的字符
+	AmbiguousChars = "0Oo1lIi|`'\""
+)
+
+// PasswordPolicy 生成密码的规则
+type PasswordPolicy struct {
+	Length           int       // 长度，为0时使用16
+	Classes          CharClass // 使用的字符类别，为0时使用全部类别，每个类别至少出现一次
+	Symbols          string    // 特殊字符集合，为空时使用DefaultSymbols
+	ExcludeAmbiguous bool      // 排除AmbiguousChars中的字符
+	Exclude          string    // 额外排除的字符
+}
+
+// GeneratePassword 按规则使用crypto/rand生成随机密码
+func GeneratePassword(p PasswordPolicy) (string, error) {
+	if p.Length == 0 {
+		p.Length = 16
+	}
+	if p.Classes == 0 {
+		p.Classes = AllClasses
+	}
+	if p.Symbols == "" {
+		p.Symbols = DefaultSymbols
+	}
+	exclude := p.Exclude
+	if p.ExcludeAmbiguous {
+		exclude += AmbiguousChars
+	}
+
+	// 按字符而非字节选取，Symbols可以包含非ASCII字符
+	var sets [][]rune
+	var all []rune
+	for _, c := range []struct {
+		class CharClass
+		chars string
+	}{{Lower, lowerChars}, {Upper, upperChars}, {Digit, digitChars}, {Symbol, p.Symbols}} {
+		if p.Classes&c.class == 0 {
+			continue
+		}
+		chars := []rune(strings.Map(func(r rune) rune {
+			if strings.ContainsRune(exclude, r) {
+				return -1
+			}
+			return r
+		}, c.chars))
+		if len(chars) == 0 {
+			return "", fmt.Errorf("排除字符后类别 %s 已没有可用字符", c.class)
+		}
+		sets = append(sets, chars)
+		all = append(all, chars...)
+	}
+	if p.Length < len(sets) {
+		return "", fmt.Errorf("长度 %d 小于字符类别数 %d", p.Length, len(sets))
+	}
+
+	// 先保证每个类别至少一个字符，其余从全部字符中选取，最后打乱顺序
+	buf := make([]rune, p.Length)
+	for i := range buf {
+		set := all
+		if i < len(sets) {
+			set = sets[i]
+		}
+		n, err := randInt(len(set))
+		if err != nil {
+			return "", err
+		}
+		buf[i] = set[n]
+	}
+	for i := len(buf) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf), nil
+}
+
+// GenerateSecret 生成n字节随机数并以URL安全的base64(无填充)返回，适用于API密钥、令牌等
+func GenerateSecret(n int) (string, error) {
+	if n <= 0 {
+		return "", errors.New("长度必须大于0")
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randInt(n int) (int, error) {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(v.Int64()), nil
+}
+
+// String 类别名称
+func (c CharClass) String() string {
+	names := make([]string, 0, 4)
+	for _, x := range []struct {
+		class CharClass
+		name  string
+	}{{Lower, "lower"}, {Upper, "upper"}, {Digit, "digit"}, {Symbol, "symbol"}} {
+		if c&x.class != 0 {
+			names = append(names, x.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// 密码强度检查不通过的原因
+const (
+	ReasonTooShort         = "too_short"
+	ReasonTooFewClasses    = "too_few_classes"
+	ReasonSequence         = "sequence"
+	ReasonRepeated         = "repeated"
+	ReasonKeyboard         = "keyboard"
+	ReasonContainsUsername = "contains_username"
+	ReasonCommon           = "common"
+)
+
+// PasswordMessages 原因对应的提示信息，按语言区分，可自行添加语言或修改文案
+// 文案中的%v按顺序替换为Reason.Args
+var PasswordMessages = map[string]map[string]string{
+	"zh": {
+		ReasonTooShort:         "密码长度不能少于%v位",
+		ReasonTooFewClasses:    "密码需要包含大写字母、小写字母、数字、特殊字符中的至少%v种",
+		ReasonSequence:         "密码不能包含连续的字符，如%v",
+		ReasonRepeated:         "密码不能包含重复的字符，如%v",
+		ReasonKeyboard:         "密码不能包含键盘上相邻的字符，如%v",
+		ReasonContainsUsername: "密码不能包含用户名",
+		ReasonCommon:           "密码过于常见",
+	},
+	"en": {
+		ReasonTooShort:         "password must be at least %v characters",
+		ReasonTooFewClasses:    "password must contain at least %v of: uppercase, lowercase, digits, symbols",
+		ReasonSequence:         "password must not contain sequential characters such as %v",
+		ReasonRepeated:         "password must not contain repeated characters such as %v",
+		ReasonKeyboard:         "password must not contain keyboard patterns such as %v",
+		ReasonContainsUsername: "password must not contain the username",
+		ReasonCommon:           "password is too common",
+	},
+}
+
+// DefaultLang 未找到指定语言时使用的语言
+var DefaultLang = "zh"
+
+// Reason 强度检查不通过的原因
+type Reason struct {
+	Code string
+	Args []any
+}
+
+// Message 返回指定语言的提示信息
+func (r Reason) Message(lang string) string {
+	msgs, ok := PasswordMessages[lang]
+	if !ok {
+		msgs = PasswordMessages[DefaultLang]
+	}
+	msg, ok := msgs[r.Code]
+	if !ok {
+		return r.Code
+	}
+	if strings.Contains(msg, "%v") {
+		return fmt.Sprintf(msg, r.Args...)
+	}
+	return msg
+}
+
+// StrengthPolicy 密码强度规则，字段为零值时不做对应检查
+type StrengthPolicy struct {
+	MinLength   int  // 最小长度
+	MinClasses  int  // 最少包含的字符类别数
+	MaxSequence int  // 允许的最长连续(abc、321)或重复(aaa)字符数，超过即不通过
+	Keyboard    bool // 检查键盘相邻字符，长度超过MaxSequence(为0时按3)即不通过
+	Common      bool // 检查常见弱密码
+}
+
+// DefaultStrengthPolicy 默认规则：至少8位、3种字符，不允许4位及以上的连续、重复或键盘相邻字符
+var DefaultStrengthPolicy = StrengthPolicy{
+	MinLength:   8,
+	MinClasses:  3,
+	MaxSequence: 3,
+	Keyboard:    true,
+	Common:      true,
+}
+
+// StrengthResult 密码强度检查结果
+type StrengthResult struct {
+	Score   int // 0~4，根据长度和字符类别估算的强度，与是否通过无关
+	Reasons []Reason
+}
+
+// OK 是否通过检查
+func (r StrengthResult) OK() bool {
+	return len(r.Reasons) == 0
+}
+
+// Messages 返回指定语言的全部提示信息
+func (r StrengthResult) Messages(lang string) []string {
+	msgs := make([]string, len(r.Reasons))
+	for i, reason := range r.Reasons {
+		msgs[i] = reason.Message(lang)
+	}
+	return msgs
+}
+
+// 键盘行、列，用于检查相邻字符，每一列单独存放，避免把相邻两列首尾(如sx3e)当作相邻
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"~!@#$%^&*()_+",
+	"1qaz", "2wsx", "3edc", "4rfv", "5tgb", "6yhn", "7ujm", "8ik,", "9ol.", "0p;/",
+}
+
+var commonPasswords = map[string]bool{
+	"password": true, "passw0rd": true, "p@ssw0rd": true, "p@ssword": true,
+	"admin": true, "admin123": true, "admin@123": true, "root": true, "root123": true,
+	"123456": true, "12345678": true, "123456789": true, "88888888": true, "66666666": true,
+	"qwerty": true, "qwerty123": true, "abc123": true, "a123456": true, "iloveyou": true,
+	"welcome": true, "letmein": true, "woaini1314": true, "5201314": true, "111111": true,
+	"000000": true, "123123": true, "1qaz2wsx": true, "1q2w3e4r": true, "qwe123": true,
+}
+
+// CheckPassword 按规则检查密码强度，username不为空时检查密码是否包含用户名(忽略大小写，含倒序)
+func CheckPassword(password, username string, p StrengthPolicy) StrengthResult {
+	var res StrengthResult
+	add := func(code string, args ...any) {
+		res.Reasons = append(res.Reasons, Reason{Code: code, Args: args})
+	}
+
+	runes := []rune(password)
+	classes := classesOf(password)
+	if p.MinLength > 0 && len(runes) < p.MinLength {
+		add(ReasonTooShort, p.MinLength)
+	}
+	if p.MinClasses > 0 && classes < p.MinClasses {
+		add(ReasonTooFewClasses, p.MinClasses)
+	}
+
+	lower := strings.ToLower(password)
+	if p.MaxSequence > 0 {
+		if s := sequence(lower, p.MaxSequence+1, 1); s != "" {
+			add(ReasonSequence, s)
+		}
+		if s := sequence(lower, p.MaxSequence+1, 0); s != "" {
+			add(ReasonRepeated, s)
+		}
+	}
+	if p.Keyboard {
+		n := p.MaxSequence
+		if n == 0 {
+			n = 3
+		}
+		if s := keyboardPattern(lower, n+1); s != "" {
+			add(ReasonKeyboard, s)
+		}
+	}
+	if username = strings.ToLower(strings.TrimSpace(username)); username != "" {
+		if strings.Contains(lower, username) || strings.Contains(lower, reverse(username)) {
+			add(ReasonContainsUsername)
+		}
+	}
+	// 常见密码后追加数字或符号(如Password1!)同样视为常见密码
+	if p.Common && (commonPasswords[lower] || commonPasswords[strings.TrimRight(lower, digitChars+DefaultSymbols)]) {
+		add(ReasonCommon)
+	}
+
+	res.Score = score(len(runes), classes)
+	return res
+}
+
+// classesOf 统计包含的字符类别数，非ASCII字符计入特殊字符
+func classesOf(s string) int {
+	var c CharClass
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			c |= Lower
+		case r >= 'A' && r <= 'Z':
+			c |= Upper
+		case r >= '0' && r <= '9':
+			c |= Digit
+		case !unicode.IsSpace(r):
+			c |= Symbol
+		}
+	}
+	n := 0
+	for ; c != 0; c &= c - 1 {
+		n++
+	}
+	return n
+}
+
+// sequence 查找长度至少为n、相邻字符差值为±step的片段，step为0时查找重复字符
+func sequence(s string, n, step int) string {
+	runes := []rune(s)
+	for start := 0; start < len(runes); {
+		end, dir := start+1, 0
+		for end < len(runes) {
+			d := int(runes[end]) - int(runes[end-1])
+			if step == 0 && d != 0 || step != 0 && d != step && d != -step {
+				break
+			}
+			if dir == 0 {
+				dir = d
+			} else if d != dir {
+				break
+			}
+			end++
+		}
+		if end-start >= n {
+			return string(runes[start:end])
+		}
+		if end-start > 1 && step != 0 {
+			// 方向改变的位置可能是下一段的起点，如"cbabc"
+			start = end - 1
+		} else {
+			start = end
+		}
+	}
+	return ""
+}
+
+// keyboardPattern 查找键盘上连续相邻(含倒序)且长度至少为n的片段
+func keyboardPattern(s string, n int) string {
+	runes := []rune(s)
+	for i := 0; i+n <= len(runes); i++ {
+		part := string(runes[i : i+n])
+		for _, row := range keyboardRows {
+			if strings.Contains(row, part) || strings.Contains(row, reverse(part)) {
+				return part
+			}
+		}
+	}
+	return ""
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// score 按 长度×log2(字符集大小) 估算熵并映射到0~4
+func score(length, classes int) int {
+	pool := []float64{0, 26, 52, 62, 94}[classes]
+	if length == 0 || pool == 0 {
+		return 0
+	}
+	bits := float64(length) * math.Log2(pool)
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 60:
+		return 2
+	case bits < 100:
+		return 3
+	default:
+		return 4
+	}
+}
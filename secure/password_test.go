@@ -0,0 +1,100 @@
+package secure
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		s, err := GeneratePassword(PasswordPolicy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 16 || classesOf(s) != 4 {
+			t.Fatalf("GeneratePassword() = %q", s)
+		}
+		for _, r := range s {
+			if !strings.ContainsRune(lowerChars+upperChars+digitChars+DefaultSymbols, r) {
+				t.Fatalf("GeneratePassword() = %q 包含 %q", s, r)
+			}
+		}
+	}
+
+	for i := 0; i < 200; i++ {
+		s, err := GeneratePassword(PasswordPolicy{Length: 12, Classes: Digit | Symbol, Symbols: "€#", ExcludeAmbiguous: true, Exclude: "9"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !utf8.ValidString(s) || utf8.RuneCountInString(s) != 12 {
+			t.Fatalf("GeneratePassword() = %q", s)
+		}
+		if strings.ContainsAny(s, "019") || !strings.ContainsAny(s, "€#") || strings.Trim(s, "2345678€#") != "" {
+			t.Fatalf("GeneratePassword() = %q", s)
+		}
+	}
+
+	for _, p := range []PasswordPolicy{
+		{Length: 3},
+		{Classes: Digit, Exclude: digitChars},
+		{Classes: Symbol, Symbols: "|", ExcludeAmbiguous: true},
+	} {
+		if s, err := GeneratePassword(p); err == nil {
+			t.Errorf("GeneratePassword(%+v) = %q, want error", p, s)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	tests := []struct {
+		password, username string
+		want               []string
+	}{
+		{"Xk9#mP2$vL", "", nil},
+		{"short1A", "", []string{ReasonTooShort}},
+		{"alllowercase", "", []string{ReasonTooFewClasses}},
+		{"Xk9#abcd$vL", "", []string{ReasonSequence}},
+		{"Xk9#4321$vL", "", []string{ReasonSequence, ReasonKeyboard}},
+		{"Xk9#aaaa$vL", "", []string{ReasonRepeated}},
+		{"Xk9#qwer$vL", "", []string{ReasonKeyboard}},
+		{"Xk9#REWQ$vL", "", []string{ReasonKeyboard}},
+		{"Xk9#1qaz$vL", "", []string{ReasonKeyboard}},
+		{"Xk9#zaq1$vL", "", []string{ReasonKeyboard}},
+		{"Xk9#sx3e$vL", "", nil},
+		{"Xk9#ik,9$vL", "", nil},
+		{"Xk9#zhang$vL", "Zhang", []string{ReasonContainsUsername}},
+		{"Xk9#gnahz$vL", "zhang", []string{ReasonContainsUsername}},
+		{"Password1!", "", []string{ReasonCommon}},
+		{"密码€€Xk9#mP2", "", nil},
+	}
+	for _, tt := range tests {
+		res := CheckPassword(tt.password, tt.username, DefaultStrengthPolicy)
+		var got []string
+		for _, r := range res.Reasons {
+			got = append(got, r.Code)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("CheckPassword(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+		if res.OK() != (len(tt.want) == 0) {
+			t.Errorf("CheckPassword(%q).OK() = %v", tt.password, res.OK())
+		}
+	}
+
+	// 报告的片段按字符截取，不会截断多字节字符
+	if s := keyboardPattern("€€€qwer", 4); s != "qwer" {
+		t.Errorf("keyboardPattern = %q", s)
+	}
+
+	res := CheckPassword("abc", "", DefaultStrengthPolicy)
+	if msg := res.Reasons[0].Message("zh"); msg != "密码长度不能少于8位" {
+		t.Errorf("Message(zh) = %q", msg)
+	}
+	if msg := res.Reasons[0].Message("xx"); msg != "密码长度不能少于8位" {
+		t.Errorf("Message(xx) = %q", msg)
+	}
+	if msgs := res.Messages("en"); msgs[0] != "password must be at least 8 characters" {
+		t.Errorf("Messages(en) = %q", msgs)
+	}
+}
@@ -0,0 +1,44 @@
+package validate
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Tags 注册到validator的标签及对应的校验函数，如 `binding:"required,idcard"`
+var Tags = map[string]func(string) bool{
+	"idcard":          IDCard,
+	"uscc":            USCC,
+	"mobile":          Mobile,
+	"postcode":        PostalCode,
+	"domain":          Domain,
+	"wildcard_domain": WildcardDomain,
+	"ipaddr":          IP,
+}
+
+// Register 将Tags中的校验注册到v，仅对字符串字段生效，其它类型校验失败
+func Register(v *validator.Validate) error {
+	for tag, fn := range Tags {
+		err := v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			if s, ok := fl.Field().Interface().(string); ok {
+				return fn(s)
+			}
+			return false
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterGin 注册到gin的binding校验器，在路由初始化前调用
+func RegisterGin() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("gin的校验器不是go-playground/validator")
+	}
+	return Register(v)
+}
@@ -0,0 +1,66 @@
+package validate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type person struct {
+	ID      string `json:"id" binding:"required,idcard"`
+	Company string `json:"company" binding:"omitempty,uscc"`
+	Mobile  string `json:"mobile" binding:"omitempty,mobile"`
+	Site    string `json:"site" binding:"omitempty,wildcard_domain"`
+}
+
+func TestRegisterGin(t *testing.T) {
+	if err := RegisterGin(); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/", func(c *gin.Context) {
+		var p person
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.String(http.StatusOK, p.ID)
+	})
+
+	tests := []struct {
+		body string
+		code int
+		tag  string
+	}{
+		{`{"id":"11010519491231002X","company":"91350100M000100Y43","mobile":"13812345678","site":"*.example.com"}`, http.StatusOK, ""},
+		{`{"id":"110105194912310021"}`, http.StatusBadRequest, "idcard"},
+		{`{"id":"11010519491231002X","company":"91350100M000100Y44"}`, http.StatusBadRequest, "uscc"},
+		{`{"id":"11010519491231002X","mobile":"12345"}`, http.StatusBadRequest, "mobile"},
+		{`{"id":"11010519491231002X","site":"localhost"}`, http.StatusBadRequest, "wildcard_domain"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+		if w.Code != tt.code || tt.tag != "" && !strings.Contains(w.Body.String(), "'"+tt.tag+"'") {
+			t.Errorf("%s: %d %s", tt.body, w.Code, w.Body)
+		}
+	}
+}
+
+func TestRegisterNonString(t *testing.T) {
+	v := validator.New()
+	if err := Register(v); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Var(13812345678, "mobile"); err == nil {
+		t.Error("非字符串字段应校验失败")
+	}
+	if err := v.Var("13812345678", "mobile"); err != nil {
+		t.Error(err)
+	}
+}
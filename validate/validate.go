@@ -0,0 +1,165 @@
+package validate
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mzky/utils/net"
+	"golang.org/x/net/idna"
+)
+
+var (
+	mobileRegexp   = regexp.MustCompile(`^(?:\+86|0086)?1[3-9]\d{9}$`)
+	postcodeRegexp = regexp.MustCompile(`^[0-8]\d{5}$`)
+	labelRegexp    = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?$`)
+	tldRegexp      = regexp.MustCompile(`^(?:[a-z]{2,63}|xn--[a-z0-9-]{1,59})$`)
+)
+
+// 身份证号前两位的省级行政区划代码
+var provinces = map[string]string{
+	"11": "北京", "12": "天津", "13": "河北", "14": "山西", "15": "内蒙古",
+	"21": "辽宁", "22": "吉林", "23": "黑龙江",
+	"31": "上海", "32": "江苏", "33": "浙江", "34": "安徽", "35": "福建", "36": "江西", "37": "山东",
+	"41": "河南", "42": "湖北", "43": "湖南", "44": "广东", "45": "广西", "46": "海南",
+	"50": "重庆", "51": "四川", "52": "贵州", "53": "云南", "54": "西藏",
+	"61": "陕西", "62": "甘肃", "63": "青海", "64": "宁夏", "65": "新疆",
+	"71": "台湾", "81": "香港", "82": "澳门", "83": "台湾",
+}
+
+// IDCardInfo 身份证号中包含的信息
+type IDCardInfo struct {
+	Province string
+	Birthday time.Time
+	Male     bool
+}
+
+// ParseIDCard 校验居民身份证号(GB 11643)并解析，18位校验省份、出生日期和校验码，15位校验省份和出生日期
+func ParseIDCard(id string) (IDCardInfo, error) {
+	var info IDCardInfo
+	id = strings.ToUpper(id)
+	var birth string
+	switch len(id) {
+	case 18:
+		if !isDigits(id[:17]) || !isDigits(id[17:]) && id[17] != 'X' {
+			return info, errors.New("身份证号只能包含数字和X")
+		}
+		if idCardCheckCode(id[:17]) != id[17] {
+			return info, errors.New("身份证号校验码不正确")
+		}
+		birth = id[6:14]
+		info.Male = (id[16]-'0')%2 == 1
+	case 15:
+		if !isDigits(id) {
+			return info, errors.New("身份证号只能包含数字")
+		}
+		birth = "19" + id[6:12]
+		info.Male = (id[14]-'0')%2 == 1
+	default:
+		return info, errors.New("身份证号长度应为18位或15位")
+	}
+
+	province, ok := provinces[id[:2]]
+	if !ok {
+		return info, errors.New("身份证号行政区划代码不正确")
+	}
+	info.Province = province
+
+	t, err := time.ParseInLocation("20060102", birth, time.Local)
+	if err != nil || t.Year() < 1900 || t.After(time.Now()) {
+		return info, errors.New("身份证号出生日期不正确")
+	}
+	info.Birthday = t
+	return info, nil
+}
+
+// IDCard 是否为合法的居民身份证号
+func IDCard(id string) bool {
+	_, err := ParseIDCard(id)
+	return err == nil
+}
+
+// idCardCheckCode 计算18位身份证号的校验码
+func idCardCheckCode(id17 string) byte {
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		sum += int(id17[i]-'0') * w
+	}
+	return "10X98765432"[sum%11]
+}
+
+// 统一社会信用代码字符集，不使用I、O、Z、S、V
+const usccChars = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+
+// USCC 是否为合法的统一社会信用代码(GB 32100)
+func USCC(code string) bool {
+	if len(code) != 18 {
+		return false
+	}
+	code = strings.ToUpper(code)
+	weights := [17]int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+	sum := 0
+	for i, w := range weights {
+		v := strings.IndexByte(usccChars, code[i])
+		if v < 0 {
+			return false
+		}
+		sum += v * w
+	}
+	check := (31 - sum%31) % 31
+	return code[17] == usccChars[check]
+}
+
+// Mobile 是否为中国大陆手机号，允许+86或0086前缀
+func Mobile(s string) bool {
+	return mobileRegexp.MatchString(s)
+}
+
+// PostalCode 是否为中国大陆邮政编码
+func PostalCode(s string) bool {
+	return postcodeRegexp.MatchString(s)
+}
+
+// Domain 是否为合法域名，忽略大小写，允许末尾的"."，支持中文等国际化域名
+func Domain(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" {
+		return false
+	}
+	ascii, err := idna.Lookup.ToASCII(s)
+	if err != nil || len(ascii) > 253 {
+		return false
+	}
+
+	labels := strings.Split(strings.ToLower(ascii), ".")
+	if len(labels) < 2 || !tldRegexp.MatchString(labels[len(labels)-1]) {
+		return false
+	}
+	for _, l := range labels {
+		if !labelRegexp.MatchString(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// WildcardDomain 是否为合法域名或泛域名，如*.example.com
+func WildcardDomain(s string) bool {
+	return Domain(strings.TrimPrefix(s, "*."))
+}
+
+// IP 是否为合法的IPv4或IPv6地址
+func IP(s string) bool {
+	return net.IsIP(s)
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,103 @@
+package validate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseIDCard(t *testing.T) {
+	tests := []struct {
+		id       string
+		province string
+		birthday string
+		male     bool
+		ok       bool
+	}{
+		{"11010519491231002X", "北京", "1949-12-31", false, true},
+		{"11010519491231002x", "北京", "1949-12-31", false, true},
+		{"440524188001010014", "广东", "1880-01-01", true, false}, // 1900年以前
+		{"110105491231002", "北京", "1949-12-31", false, true},
+		{"110105491231001", "北京", "1949-12-31", true, true},
+		{"110105194912310021", "", "", false, false}, // 校验码错误
+		{"11010519491231002Y", "", "", false, false},
+		{"110105194902300020", "", "", false, false}, // 2月30日
+		{"110105490230002", "", "", false, false},
+		{"990105194912310020", "", "", false, false}, // 省份代码
+		{"11010520991231002", "", "", false, false},  // 长度
+		{"1101051949123100", "", "", false, false},
+		{"11010A491231002", "", "", false, false},
+		{"", "", "", false, false},
+	}
+	for _, tt := range tests {
+		info, err := ParseIDCard(tt.id)
+		if (err == nil) != tt.ok || IDCard(tt.id) != tt.ok {
+			t.Errorf("ParseIDCard(%q) err = %v, want ok=%v", tt.id, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+		if info.Province != tt.province || info.Birthday.Format(time.DateOnly) != tt.birthday || info.Male != tt.male {
+			t.Errorf("ParseIDCard(%q) = %+v", tt.id, info)
+		}
+	}
+
+	// 未来的出生日期
+	future := "110105" + time.Now().AddDate(0, 0, 1).Format("20060102") + "001"
+	future += string(idCardCheckCode(future))
+	if IDCard(future) {
+		t.Errorf("IDCard(%q) 出生日期晚于今天", future)
+	}
+}
+
+func TestUSCC(t *testing.T) {
+	tests := []struct {
+		code string
+		ok   bool
+	}{
+		{"91350100M000100Y43", true},
+		{"91350100m000100y43", true},
+		{"91350100M000100Y44", false},
+		{"91350100M000100Y4", false},
+		{"91350100M000100I43", false}, // 不使用I
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := USCC(tt.code); got != tt.ok {
+			t.Errorf("USCC(%q) = %v, want %v", tt.code, got, tt.ok)
+		}
+	}
+}
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(string) bool
+		in   string
+		ok   bool
+	}{
+		{"Mobile", Mobile, "13812345678", true},
+		{"Mobile", Mobile, "+8613812345678", true},
+		{"Mobile", Mobile, "008613812345678", true},
+		{"Mobile", Mobile, "12812345678", false},
+		{"Mobile", Mobile, "1381234567", false},
+		{"PostalCode", PostalCode, "100000", true},
+		{"PostalCode", PostalCode, "900000", false},
+		{"Domain", Domain, "example.com", true},
+		{"Domain", Domain, "Example.COM.", true},
+		{"Domain", Domain, "中文.中国", true},
+		{"Domain", Domain, "localhost", false},
+		{"Domain", Domain, "-a.com", false},
+		{"Domain", Domain, "a..com", false},
+		{"WildcardDomain", WildcardDomain, "*.example.com", true},
+		{"WildcardDomain", WildcardDomain, "*.*.example.com", false},
+		{"IP", IP, "192.168.0.1", true},
+		{"IP", IP, "::1", true},
+		{"IP", IP, "256.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.in); got != tt.ok {
+			t.Errorf("%s(%q) = %v, want %v", tt.name, tt.in, got, tt.ok)
+		}
+	}
+}